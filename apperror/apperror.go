@@ -0,0 +1,106 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Error adalah error aplikasi yang membawa status HTTP, kode stabil yang bisa
+// dibaca mesin (contoh PRODUCT_NOT_FOUND) dan pesan untuk ditampilkan ke user.
+// Details opsional, misalnya daftar field yang tidak valid.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Details interface{}
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is membuat errors.Is cocok dengan sentinel berdasarkan kode, sehingga
+// error hasil WithDetails atau Wrap tetap dikenali sebagai sentinel asalnya.
+func (e *Error) Is(target error) bool {
+	var t *Error
+	if !errors.As(target, &t) {
+		return false
+	}
+	return t.Code == e.Code
+}
+
+// WithDetails mengembalikan salinan error dengan details
+func (e *Error) WithDetails(details interface{}) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// Wrap mengembalikan salinan error yang membungkus penyebabnya untuk logging
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// WithMessage mengembalikan salinan error dengan pesan lain
+func (e *Error) WithMessage(message string) *Error {
+	c := *e
+	c.Message = message
+	return &c
+}
+
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func BadRequest(code, message string) *Error {
+	return New(http.StatusBadRequest, code, message)
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(http.StatusUnauthorized, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(http.StatusForbidden, code, message)
+}
+
+func NotFound(code, message string) *Error {
+	return New(http.StatusNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(http.StatusConflict, code, message)
+}
+
+func Unprocessable(code, message string) *Error {
+	return New(http.StatusUnprocessableEntity, code, message)
+}
+
+// Error umum yang dipakai lintas handler
+var (
+	ErrInvalidBody  = BadRequest("INVALID_REQUEST_BODY", "ada kesalahan saat mengambil data")
+	ErrInvalidQuery = BadRequest("INVALID_QUERY_PARAM", "parameter query tidak valid")
+	ErrMissingID    = BadRequest("MISSING_ID", "id tidak boleh kosong")
+	ErrValidation   = Unprocessable("VALIDATION_ERROR", "request tidak valid")
+	ErrInternal     = New(http.StatusInternalServerError, "INTERNAL_ERROR", "terjadi kesalahan pada server")
+)
+
+// From mengambil *Error dari rantai error. Error yang tidak dikenal dianggap
+// ErrInternal supaya pesan dari database tidak bocor ke client.
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return ErrInternal.Wrap(err)
+}
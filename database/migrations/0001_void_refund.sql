@@ -0,0 +1,27 @@
+-- Void dan refund transaksi
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'completed',
+    ADD COLUMN IF NOT EXISTS voided_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS void_reason TEXT;
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id),
+    type VARCHAR(20) NOT NULL, -- 'void' atau 'refund'
+    reason TEXT NOT NULL,
+    total_amount INT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS refund_details (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    refund_id UUID NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    transaction_detail_id UUID NOT NULL REFERENCES transaction_details(id),
+    product_id UUID NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    amount INT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_refunds_transaction_id ON refunds(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_created_at ON refunds(created_at);
+CREATE INDEX IF NOT EXISTS idx_refund_details_transaction_detail_id ON refund_details(transaction_detail_id);
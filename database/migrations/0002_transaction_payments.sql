@@ -0,0 +1,14 @@
+-- Pembayaran per transaksi (bisa lebih dari satu metode)
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS paid_amount INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS change_amount INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS transaction_payments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    method VARCHAR(20) NOT NULL, -- cash, qris, debit_card, e_wallet
+    tendered INT NOT NULL,        -- jumlah yang diserahkan pelanggan
+    amount INT NOT NULL           -- jumlah yang dipakai untuk membayar (tendered - kembalian)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_payments_transaction_id ON transaction_payments(transaction_id);
@@ -0,0 +1,7 @@
+-- Idempotency-Key untuk POST /transactions/checkout
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key VARCHAR(255) PRIMARY KEY,
+    request_hash VARCHAR(64) NOT NULL,
+    transaction_id UUID REFERENCES transactions(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
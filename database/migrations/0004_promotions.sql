@@ -0,0 +1,31 @@
+-- Promosi dan diskon
+CREATE TABLE IF NOT EXISTS promotions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL,  -- percentage, fixed, buy_x_get_y
+    scope VARCHAR(20) NOT NULL, -- product, category, cart
+    product_id UUID REFERENCES products(id) ON DELETE CASCADE,
+    category_id UUID REFERENCES categories(id) ON DELETE CASCADE,
+    value INT NOT NULL DEFAULT 0,
+    buy_qty INT NOT NULL DEFAULT 0,
+    get_qty INT NOT NULL DEFAULT 0,
+    min_spend INT NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS gross_amount INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS discount_amount INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS promotion_id UUID REFERENCES promotions(id) ON DELETE SET NULL;
+
+ALTER TABLE transaction_details
+    ADD COLUMN IF NOT EXISTS gross_amount INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS discount_amount INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS promotion_id UUID REFERENCES promotions(id) ON DELETE SET NULL;
+
+-- transaksi lama tidak memiliki diskon, jadi gross sama dengan total
+UPDATE transactions SET gross_amount = total_amount WHERE gross_amount = 0;
+UPDATE transaction_details SET gross_amount = subtotal WHERE gross_amount = 0;
@@ -0,0 +1,13 @@
+-- PPN dan service charge
+ALTER TABLE categories
+    ADD COLUMN IF NOT EXISTS tax_exempt BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS service_charge_amount INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_amount INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_rate NUMERIC(5,2) NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_inclusive BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE transaction_details
+    ADD COLUMN IF NOT EXISTS service_charge_amount INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tax_amount INT NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+-- Audit perubahan katalog dan transaksi
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor_id UUID,                -- NULL jika perubahan dilakukan sistem
+    actor_username VARCHAR(100),  -- disalin agar tetap terbaca walau user dihapus
+    entity VARCHAR(30) NOT NULL,  -- product, category, transaction
+    entity_id VARCHAR(100) NOT NULL,
+    action VARCHAR(20) NOT NULL,  -- create, update, delete, void, refund
+    before_data JSONB,
+    after_data JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity ON audit_logs (entity, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs (created_at);
@@ -0,0 +1,22 @@
+-- Ledger pergerakan stok. products.stock tetap disimpan sebagai saldo
+-- terakhir, setiap perubahannya dicatat di sini.
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    type VARCHAR(30) NOT NULL, -- sale, refund, purchase_receipt, adjustment, waste, stock_take
+    quantity INT NOT NULL,     -- positif menambah stok, negatif mengurangi
+    stock_after INT NOT NULL,
+    reference_id UUID,         -- transaksi, refund, penerimaan barang atau sesi stock take
+    reason TEXT,
+    actor_id UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id ON stock_movements (product_id, created_at);
+
+-- saldo awal untuk produk yang sudah ada sebelum ledger dipakai
+INSERT INTO stock_movements (product_id, type, quantity, stock_after, reason)
+SELECT p.id, 'adjustment', p.stock, p.stock, 'saldo awal'
+FROM products p
+WHERE p.stock <> 0
+  AND NOT EXISTS (SELECT 1 FROM stock_movements sm WHERE sm.product_id = p.id);
@@ -0,0 +1,23 @@
+-- Sesi stock take (hitung fisik stok)
+CREATE TABLE IF NOT EXISTS stock_takes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, finalized, cancelled
+    note TEXT,
+    opened_by UUID,
+    opened_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    closed_by UUID,
+    closed_at TIMESTAMP
+);
+
+-- system_stock adalah stok sistem saat produk pertama kali dihitung. Saat
+-- finalisasi, selisih counted_qty - system_stock ditambahkan ke stok terkini
+-- sehingga penjualan selama proses hitung tidak ikut terkoreksi.
+CREATE TABLE IF NOT EXISTS stock_take_counts (
+    stock_take_id UUID NOT NULL REFERENCES stock_takes(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    system_stock INT NOT NULL,
+    counted_qty INT NOT NULL,
+    counted_by UUID,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (stock_take_id, product_id)
+);
@@ -0,0 +1,17 @@
+-- Harga pokok (HPP) produk dan snapshot HPP per baris transaksi
+ALTER TABLE products ADD COLUMN IF NOT EXISTS cost_price INT NOT NULL DEFAULT 0;
+
+-- cost_price adalah HPP per unit saat produk terjual; transaksi lama tetap 0
+-- karena HPP saat itu tidak diketahui
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS cost_price INT NOT NULL DEFAULT 0;
+
+-- isi HPP awal dari harga beli penerimaan barang terakhir
+UPDATE products p
+SET cost_price = gri.cost_price
+FROM (
+    SELECT DISTINCT ON (gri.product_id) gri.product_id, gri.cost_price
+    FROM goods_receipt_items gri
+    JOIN goods_receipts gr ON gr.id = gri.goods_receipt_id
+    ORDER BY gri.product_id, gr.created_at DESC
+) gri
+WHERE p.id = gri.product_id AND p.cost_price = 0;
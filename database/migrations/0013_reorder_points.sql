@@ -0,0 +1,7 @@
+-- Titik pemesanan ulang per produk. Produk dianggap stok menipis jika
+-- reorder_point > 0 dan stock <= reorder_point; reorder_qty adalah jumlah
+-- yang disarankan untuk dipesan ulang.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reorder_point INT NOT NULL DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reorder_qty INT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_products_low_stock ON products (stock) WHERE reorder_point > 0;
@@ -0,0 +1,35 @@
+-- Shift kasir. Satu kasir hanya boleh memiliki satu shift open. Saat ditutup,
+-- expected_cash dan counted_cash disimpan agar selisih kas tidak berubah
+-- walaupun cara perhitungan berubah di kemudian hari.
+CREATE TABLE IF NOT EXISTS shifts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    cashier_id UUID NOT NULL REFERENCES users(id),
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- open, closed
+    opening_float INT NOT NULL DEFAULT 0,
+    note TEXT,
+    opened_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    closed_by UUID,
+    closed_at TIMESTAMP,
+    expected_cash INT,
+    counted_cash INT
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shifts_open_cashier ON shifts (cashier_id) WHERE status = 'open';
+
+-- Kas masuk dan keluar di luar penjualan, contoh tambahan uang kembalian,
+-- kas kecil atau setoran ke bank
+CREATE TABLE IF NOT EXISTS shift_cash_movements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    shift_id UUID NOT NULL REFERENCES shifts(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL, -- cash_in, cash_out
+    amount INT NOT NULL,
+    reason TEXT NOT NULL,
+    created_by UUID,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_shift_cash_movements_shift_id ON shift_cash_movements (shift_id);
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS shift_id UUID REFERENCES shifts(id);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_shift_id ON transactions (shift_id);
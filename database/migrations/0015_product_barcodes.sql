@@ -0,0 +1,13 @@
+-- SKU unik per produk (opsional) dan satu atau lebih barcode per produk.
+-- Barcode disimpan sebagai EAN-13; UPC-A diberi awalan 0.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS sku VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (sku) WHERE sku IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS product_barcodes (
+    barcode VARCHAR(13) PRIMARY KEY,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_barcodes_product_id ON product_barcodes (product_id);
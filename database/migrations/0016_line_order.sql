@@ -0,0 +1,5 @@
+-- Urutan baris detail dan pembayaran sesuai urutan di request checkout. id
+-- berupa UUID acak sehingga tidak bisa dipakai untuk mengurutkan. Data lama
+-- bernilai 0 dan diurutkan berdasarkan id seperti sebelumnya.
+ALTER TABLE transaction_details ADD COLUMN IF NOT EXISTS line_no INT NOT NULL DEFAULT 0;
+ALTER TABLE transaction_payments ADD COLUMN IF NOT EXISTS line_no INT NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+-- Masa berlaku promosi disimpan sebagai TIMESTAMPTZ agar offset zona waktu
+-- yang dikirim client tidak hilang. Nilai lama dianggap berada di zona waktu
+-- sesi database, sama seperti sebelumnya saat dibandingkan dengan NOW().
+ALTER TABLE promotions
+    ALTER COLUMN starts_at TYPE TIMESTAMPTZ USING starts_at::timestamptz,
+    ALTER COLUMN ends_at TYPE TIMESTAMPTZ USING ends_at::timestamptz;
@@ -0,0 +1,13 @@
+-- Idempotency-Key berlaku per kasir sehingga kasir lain yang kebetulan
+-- memakai key yang sama tidak menerima transaksi milik kasir pertama.
+-- Key lama diisi kasir dari transaksinya.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS cashier_id UUID REFERENCES users(id);
+
+UPDATE idempotency_keys k SET cashier_id = t.cashier_id
+FROM transactions t
+WHERE t.id = k.transaction_id AND k.cashier_id IS NULL;
+
+ALTER TABLE idempotency_keys DROP CONSTRAINT IF EXISTS idempotency_keys_pkey;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_keys_cashier_key
+    ON idempotency_keys (COALESCE(cashier_id, '00000000-0000-0000-0000-000000000000'::uuid), key);
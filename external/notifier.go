@@ -0,0 +1,121 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"labkoding.my.id/kasir-api/models"
+)
+
+// LogNotifier mencatat event stok menipis ke log aplikasi
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) NotifyLowStock(ctx context.Context, event models.LowStockEvent) error {
+	for _, product := range event.Products {
+		slog.Warn("low_stock",
+			slog.String("transaction_id", event.TransactionID),
+			slog.String("product_id", product.ProductID),
+			slog.String("product_name", product.ProductName),
+			slog.Int("stock", product.Stock),
+			slog.Int("reorder_point", product.ReorderPoint),
+			slog.Int("reorder_qty", product.ReorderQty),
+		)
+	}
+	return nil
+}
+
+// WebhookNotifier mengirim event stok menipis sebagai JSON lewat HTTP POST
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *WebhookNotifier) NotifyLowStock(ctx context.Context, event models.LowStockEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook stok menipis membalas status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailNotifier mengirim event stok menipis lewat SMTP tanpa autentikasi,
+// ditujukan untuk relay lokal seperti Mailpit atau MailHog
+type EmailNotifier struct {
+	addr string
+	from string
+	to   []string
+}
+
+func NewEmailNotifier(host string, port int, from string, to []string) *EmailNotifier {
+	return &EmailNotifier{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+		to:   to,
+	}
+}
+
+func (n *EmailNotifier) NotifyLowStock(ctx context.Context, event models.LowStockEvent) error {
+	var body strings.Builder
+	body.WriteString("Produk berikut sudah mencapai batas stok minimum:\r\n\r\n")
+	for _, product := range event.Products {
+		fmt.Fprintf(&body, "- %s: stok %d (batas %d), pesan ulang %d\r\n", product.ProductName, product.Stock, product.ReorderPoint, product.ReorderQty)
+	}
+	fmt.Fprintf(&body, "\r\nTransaksi: %s\r\n", event.TransactionID)
+
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&msg, "Subject: Stok menipis: %d produk\r\n", len(event.Products))
+	fmt.Fprintf(&msg, "Date: %s\r\n", event.CreatedAt.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	msg.WriteString(body.String())
+
+	// net/smtp tidak menerima context, jadi batas waktu mengikuti deadline ctx
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(n.addr, nil, n.from, n.to, []byte(msg.String()))
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"labkoding.my.id/kasir-api/apperror"
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/services"
+)
+
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{
+		service: service,
+	}
+}
+
+func (h *AuditHandler) GetAllAuditLogs(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	logs, err := h.service.GetAllAuditLogs(filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, logs)
+}
+
+// parseAuditFilter membaca parameter query pagination dan filter audit log
+func parseAuditFilter(r *http.Request) (models.AuditFilter, error) {
+	q := r.URL.Query()
+	filter := models.AuditFilter{
+		Entity:    q.Get("entity"),
+		EntityID:  q.Get("entity_id"),
+		ActorID:   q.Get("actor_id"),
+		StartDate: q.Get("start_date"),
+		EndDate:   q.Get("end_date"),
+	}
+
+	if filter.Entity != "" && !slices.Contains(models.AuditEntities, filter.Entity) {
+		return filter, apperror.ErrInvalidQuery.WithMessage("entity harus salah satu dari " + strings.Join(models.AuditEntities, ", "))
+	}
+
+	if p := q.Get("page"); p != "" {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return filter, apperror.ErrInvalidQuery.WithMessage("page harus berupa angka yang valid")
+		}
+		filter.Page = v
+	}
+	if l := q.Get("limit"); l != "" {
+		v, err := strconv.Atoi(l)
+		if err != nil {
+			return filter, apperror.ErrInvalidQuery.WithMessage("limit harus berupa angka yang valid")
+		}
+		filter.Limit = v
+	}
+
+	return filter, nil
+}
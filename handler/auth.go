@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"labkoding.my.id/kasir-api/apperror"
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/services"
+)
+
+var (
+	errMissingToken = apperror.Unauthorized("MISSING_TOKEN", "header Authorization: Bearer <token> wajib diisi")
+	errForbidden    = apperror.Forbidden("FORBIDDEN", "role anda tidak memiliki akses ke endpoint ini")
+)
+
+type contextKey string
+
+const authUserKey contextKey = "auth_user"
+
+type AuthHandler struct {
+	service *services.AuthService
+}
+
+func NewAuthHandler(service *services.AuthService) *AuthHandler {
+	return &AuthHandler{
+		service: service,
+	}
+}
+
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req models.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, apperror.ErrInvalidBody.Wrap(err))
+		return
+	}
+
+	resp, err := h.service.Login(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Me mengembalikan user yang sedang login
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, currentUser(r))
+}
+
+// Authenticate mewajibkan header Authorization: Bearer <token> dan menyimpan
+// user yang login ke context request
+func (h *AuthHandler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			writeError(w, errMissingToken)
+			return
+		}
+
+		user, err := h.service.Authenticate(token)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), authUserKey, user)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireRole hanya meneruskan request dari user dengan salah satu role
+// tersebut. Harus dipasang setelah Authenticate.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := currentUser(r)
+			if user == nil {
+				writeError(w, errMissingToken)
+				return
+			}
+			if !slices.Contains(roles, user.Role) {
+				writeError(w, errForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// currentUser mengambil user yang login dari context, nil jika route tidak
+// melewati Authenticate
+func currentUser(r *http.Request) *models.AuthUser {
+	user, _ := r.Context().Value(authUserKey).(*models.AuthUser)
+	return user
+}
@@ -2,21 +2,23 @@ package handler
 
 import (
 	"encoding/json"
-	"log/slog"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
+	"labkoding.my.id/kasir-api/apperror"
 	"labkoding.my.id/kasir-api/models"
 	"labkoding.my.id/kasir-api/services"
 )
 
 type CategoryHandler struct {
-	service *services.CategoryService
+	service        *services.CategoryService
+	productService *services.ProductService
 }
 
-func NewCategoryHandler(service *services.CategoryService) *CategoryHandler {
+func NewCategoryHandler(service *services.CategoryService, productService *services.ProductService) *CategoryHandler {
 	return &CategoryHandler{
-		service: service,
+		service:        service,
+		productService: productService,
 	}
 }
 
@@ -24,11 +26,15 @@ func (h *CategoryHandler) GetAllCategory(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Content-Type", "application/json")
 
 	name := r.URL.Query().Get("name")
-	categories, err := h.service.GetAllCategories(name)
+	includeProducts := r.URL.Query().Get("include") == "products"
+	categories, err := h.service.GetAllCategories(name, includeProducts)
 	if err != nil {
-		slog.Error(err.Error())
+		writeError(w, err)
 		return
 	}
+	for i := range categories {
+		hideCostPrice(r, categories[i].Products)
+	}
 
 	json.NewEncoder(w).Encode(categories)
 
@@ -40,15 +46,13 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 	var category models.CategoryRequest
 	err := json.NewDecoder(r.Body).Decode(&category)
 	if err != nil {
-		http.Error(w, "ada kesalahan saat mengambil data", http.StatusBadRequest)
-		slog.Error(err.Error())
+		writeError(w, apperror.ErrInvalidBody.Wrap(err))
 		return
 	}
 
-	err = h.service.CreateCategory(&category)
+	err = h.service.CreateCategory(&category, currentUser(r))
 	if err != nil {
-		http.Error(w, "ada kesalahan saat membuat kategori", http.StatusBadRequest)
-		slog.Error(err.Error())
+		writeError(w, err)
 		return
 	}
 
@@ -61,23 +65,21 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		http.Error(w, "id tidak boleh kosong", http.StatusBadRequest)
+		writeError(w, apperror.ErrMissingID)
 		return
 	}
 
 	var category models.CategoryRequest
 	err := json.NewDecoder(r.Body).Decode(&category)
 	if err != nil {
-		http.Error(w, "ada kesalahan saat mengambil data", http.StatusBadRequest)
-		slog.Error(err.Error())
+		writeError(w, apperror.ErrInvalidBody.Wrap(err))
 		return
 	}
 
 	category.ID = id
-	err = h.service.UpdateCategory(&category)
+	err = h.service.UpdateCategory(&category, currentUser(r))
 	if err != nil {
-		http.Error(w, "ada kesalahan saat mengupdate category", http.StatusBadRequest)
-		slog.Error(err.Error())
+		writeError(w, err)
 		return
 	}
 
@@ -89,10 +91,9 @@ func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Content-Type", "application/json")
 
 	id := chi.URLParam(r, "id")
-	err := h.service.DeleteCategory(id)
+	err := h.service.DeleteCategory(id, currentUser(r))
 	if err != nil {
-		http.Error(w, "ada kesalahan saat menghapus category", http.StatusBadRequest)
-		slog.Error(err.Error())
+		writeError(w, err)
 		return
 	}
 
@@ -106,10 +107,37 @@ func (h *CategoryHandler) GetCategoryByID(w http.ResponseWriter, r *http.Request
 	id := chi.URLParam(r, "id")
 	category, err := h.service.GetCategoryByID(id)
 	if err != nil {
-		http.Error(w, "category tidak ditemukan", http.StatusNotFound)
-		slog.Error(err.Error())
+		writeError(w, err)
 		return
 	}
 
 	json.NewEncoder(w).Encode(category)
 }
+
+// GetCategoryProducts mengembalikan produk dalam satu kategori dengan
+// pagination dan filter yang sama seperti GET /products.
+func (h *CategoryHandler) GetCategoryProducts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+	if _, err := h.service.GetCategoryByID(id); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	filter.CategoryID = id
+
+	products, err := h.productService.GetAllProducts(filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	hideCostPrice(r, products.Data)
+
+	json.NewEncoder(w).Encode(products)
+}
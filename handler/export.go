@@ -0,0 +1,371 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+	"labkoding.my.id/kasir-api/apperror"
+)
+
+const (
+	exportFormatCSV  = "csv"
+	exportFormatXLSX = "xlsx"
+)
+
+// exportFlushRows adalah jumlah baris CSV yang dikirim ke client sekaligus
+const exportFlushRows = 500
+
+// rupiah menandai nilai uang. CSV menulisnya sebagai teks "Rp 1.500.000",
+// XLSX sebagai angka dengan format Rupiah agar tetap bisa dijumlahkan.
+type rupiah int
+
+// percent menandai persentase dalam satuan persen, contoh 33.4 untuk 33,4%
+type percent float64
+
+// parseExportFormat membaca query format. String kosong berarti respons JSON.
+func parseExportFormat(r *http.Request) (string, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		return "", nil
+	case exportFormatCSV, exportFormatXLSX:
+		return format, nil
+	default:
+		return "", apperror.ErrInvalidQuery.WithMessage("format harus salah satu dari json, csv, xlsx")
+	}
+}
+
+// exportWriter menulis satu atau beberapa tabel ke file ekspor. Pada XLSX
+// setiap tabel menjadi sheet tersendiri; pada CSV tabel berikutnya dipisah
+// baris kosong dan baris judul.
+type exportWriter interface {
+	StartTable(title string, header []string) error
+	WriteRow(values ...interface{}) error
+	Close() error
+}
+
+func newExportWriter(w http.ResponseWriter, format, filename string) (exportWriter, error) {
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+"."+format))
+	if format == exportFormatXLSX {
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		return newXLSXExportWriter(w)
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	return &csvExportWriter{w: w, csv: csv.NewWriter(w)}, nil
+}
+
+// tableExport menulis satu tabel yang barisnya datang satu per satu dari
+// repository. File baru dibuat saat baris pertama ditulis, sehingga error
+// sebelum itu (misalnya query gagal) masih bisa dikirim sebagai JSON.
+type tableExport struct {
+	w        http.ResponseWriter
+	format   string
+	filename string
+	title    string
+	header   []string
+	writer   exportWriter
+}
+
+func (e *tableExport) start() error {
+	writer, err := newExportWriter(e.w, e.format, e.filename)
+	if err != nil {
+		return err
+	}
+	if err := writer.StartTable(e.title, e.header); err != nil {
+		return err
+	}
+	e.writer = writer
+	return nil
+}
+
+func (e *tableExport) WriteRow(values ...interface{}) error {
+	if e.writer == nil {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+	return e.writer.WriteRow(values...)
+}
+
+// finish menutup file ekspor. Error sebelum file dibuat dikirim sebagai JSON;
+// error setelahnya ditangani failExport.
+func (e *tableExport) finish(err error) {
+	if err == nil && e.writer == nil {
+		err = e.start()
+	}
+	if err != nil {
+		if e.writer == nil {
+			e.w.Header().Del("Content-Disposition")
+			writeError(e.w, err)
+			return
+		}
+		failExport(e.w, e.writer, e.filename, err)
+		return
+	}
+	if err := e.writer.Close(); err != nil {
+		slog.Error("export_failed", slog.String("file", e.filename), slog.String("error", err.Error()))
+	}
+}
+
+// failExport menangani error setelah file ekspor mulai ditulis. XLSX baru
+// dikirim saat Close sehingga belum ada yang terkirim dan error masih bisa
+// dikembalikan sebagai JSON. CSV mungkin sudah terkirim sebagian; status
+// response sudah terkirim sehingga error hanya dicatat ke log.
+func failExport(w http.ResponseWriter, writer exportWriter, filename string, err error) {
+	if x, ok := writer.(*xlsxExportWriter); ok {
+		x.file.Close()
+		w.Header().Del("Content-Disposition")
+		writeError(w, err)
+		return
+	}
+	slog.Error("export_failed", slog.String("file", filename), slog.String("error", err.Error()))
+}
+
+type csvExportWriter struct {
+	w      http.ResponseWriter
+	csv    *csv.Writer
+	tables int
+	rows   int
+}
+
+func (c *csvExportWriter) StartTable(title string, header []string) error {
+	if c.tables > 0 {
+		c.csv.Write(nil)
+		c.csv.Write([]string{title})
+	}
+	c.tables++
+	return c.csv.Write(header)
+}
+
+func (c *csvExportWriter) WriteRow(values ...interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = formatCSVValue(v)
+	}
+	if err := c.csv.Write(record); err != nil {
+		return err
+	}
+
+	c.rows++
+	if c.rows%exportFlushRows == 0 {
+		c.csv.Flush()
+		if flusher, ok := c.w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+	return c.csv.Error()
+}
+
+func (c *csvExportWriter) Close() error {
+	c.csv.Flush()
+	return c.csv.Error()
+}
+
+func formatCSVValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case rupiah:
+		return formatRupiah(int(v))
+	case percent:
+		return strconv.FormatFloat(float64(v), 'f', -1, 64) + "%"
+	case string:
+		return escapeCSVFormula(v)
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return escapeCSVFormula(*v)
+	case int:
+		return strconv.Itoa(v)
+	case *int:
+		if v == nil {
+			return ""
+		}
+		return strconv.Itoa(*v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "ya"
+		}
+		return "tidak"
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format("2006-01-02 15:04:05")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// escapeCSVFormula memberi awalan ' pada teks yang diawali =, +, -, @, tab
+// atau carriage return agar tidak dijalankan sebagai rumus saat CSV dibuka di
+// spreadsheet, misalnya nama produk atau alasan yang diketik user
+func escapeCSVFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// formatRupiah menulis nilai uang dengan pemisah ribuan titik, contoh
+// "Rp 1.500.000" atau "-Rp 25.000"
+func formatRupiah(amount int) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.Itoa(amount)
+	grouped := make([]byte, 0, len(digits)+len(digits)/3)
+	for i := range len(digits) {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped = append(grouped, '.')
+		}
+		grouped = append(grouped, digits[i])
+	}
+	return sign + "Rp " + string(grouped)
+}
+
+// xlsxExportWriter memakai StreamWriter excelize yang menulis baris demi baris
+// ke file sementara, lalu mengirim file utuh ke client saat Close
+type xlsxExportWriter struct {
+	w      http.ResponseWriter
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	sheets int
+	row    int
+
+	headerStyle  int
+	rupiahStyle  int
+	percentStyle int
+	timeStyle    int
+}
+
+func newXLSXExportWriter(w http.ResponseWriter) (*xlsxExportWriter, error) {
+	file := excelize.NewFile()
+	x := &xlsxExportWriter{w: w, file: file}
+
+	rupiahFormat := `"Rp" #,##0;-"Rp" #,##0`
+	timeFormat := "yyyy-mm-dd hh:mm:ss"
+	styles := []struct {
+		dest  *int
+		style *excelize.Style
+	}{
+		{&x.headerStyle, &excelize.Style{Font: &excelize.Font{Bold: true}}},
+		{&x.rupiahStyle, &excelize.Style{CustomNumFmt: &rupiahFormat}},
+		{&x.percentStyle, &excelize.Style{NumFmt: 10}},
+		{&x.timeStyle, &excelize.Style{CustomNumFmt: &timeFormat}},
+	}
+	for _, s := range styles {
+		id, err := file.NewStyle(s.style)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		*s.dest = id
+	}
+	return x, nil
+}
+
+func (x *xlsxExportWriter) StartTable(title string, header []string) error {
+	if x.stream != nil {
+		if err := x.stream.Flush(); err != nil {
+			return err
+		}
+	}
+
+	// file baru sudah memiliki Sheet1, dipakai untuk tabel pertama
+	if x.sheets == 0 {
+		if err := x.file.SetSheetName("Sheet1", title); err != nil {
+			return err
+		}
+	} else if _, err := x.file.NewSheet(title); err != nil {
+		return err
+	}
+	x.sheets++
+
+	stream, err := x.file.NewStreamWriter(title)
+	if err != nil {
+		return err
+	}
+	if err := stream.SetColWidth(1, len(header), 18); err != nil {
+		return err
+	}
+	x.stream = stream
+	x.row = 0
+
+	cells := make([]interface{}, len(header))
+	for i, h := range header {
+		cells[i] = excelize.Cell{StyleID: x.headerStyle, Value: h}
+	}
+	return x.writeCells(cells)
+}
+
+func (x *xlsxExportWriter) WriteRow(values ...interface{}) error {
+	cells := make([]interface{}, len(values))
+	for i, v := range values {
+		cells[i] = x.cell(v)
+	}
+	return x.writeCells(cells)
+}
+
+func (x *xlsxExportWriter) writeCells(cells []interface{}) error {
+	x.row++
+	axis, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(axis, cells)
+}
+
+func (x *xlsxExportWriter) cell(v interface{}) interface{} {
+	switch v := v.(type) {
+	case rupiah:
+		return excelize.Cell{StyleID: x.rupiahStyle, Value: int(v)}
+	case percent:
+		return excelize.Cell{StyleID: x.percentStyle, Value: float64(v) / 100}
+	case time.Time:
+		return excelize.Cell{StyleID: x.timeStyle, Value: v}
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return excelize.Cell{StyleID: x.timeStyle, Value: *v}
+	case *string:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *int:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case bool:
+		return formatCSVValue(v)
+	default:
+		return v
+	}
+}
+
+func (x *xlsxExportWriter) Close() error {
+	defer x.file.Close()
+	if x.stream != nil {
+		if err := x.stream.Flush(); err != nil {
+			return err
+		}
+	}
+	_, err := x.file.WriteTo(x.w)
+	return err
+}
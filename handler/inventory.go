@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"net/http"
+
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/services"
+)
+
+type InventoryHandler struct {
+	service *services.InventoryService
+}
+
+func NewInventoryHandler(service *services.InventoryService) *InventoryHandler {
+	return &InventoryHandler{
+		service: service,
+	}
+}
+
+func (h *InventoryHandler) GetLowStockProducts(w http.ResponseWriter, r *http.Request) {
+	filter := models.LowStockFilter{
+		CategoryID: r.URL.Query().Get("category_id"),
+	}
+
+	response, err := h.service.GetLowStockProducts(filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
@@ -2,17 +2,19 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
-	"log/slog"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"labkoding.my.id/kasir-api/apperror"
 	"labkoding.my.id/kasir-api/models"
 	"labkoding.my.id/kasir-api/services"
 )
 
+var errImageUploadFailed = apperror.New(http.StatusBadGateway, "IMAGE_UPLOAD_FAILED", "gagal mengupload gambar")
+
 type Producthandler struct {
 	service *services.ProductService
 }
@@ -26,19 +28,134 @@ func NewProductHandler(service *services.ProductService) *Producthandler {
 func (h *Producthandler) GetAllProduct(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	name := r.URL.Query().Get("name")
-	products, err := h.service.GetAllProducts(name)
+	filter, err := parseProductFilter(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	format, err := parseExportFormat(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if format != "" {
+		h.exportProducts(w, format, filter, canSeeCostPrice(r))
+		return
+	}
 
+	products, err := h.service.GetAllProducts(filter)
 	if err != nil {
-		slog.Error(err.Error())
-		http.Error(w, "ada kesalahan saat mengambil produk", http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
+	hideCostPrice(r, products.Data)
 
 	json.NewEncoder(w).Encode(products)
 
 }
 
+// canSeeCostPrice bernilai true jika user boleh melihat HPP (cost_price).
+// HPP hanya untuk manager dan owner, sama seperti laporan margin.
+func canSeeCostPrice(r *http.Request) bool {
+	user := currentUser(r)
+	return user != nil && user.Role != models.RoleCashier
+}
+
+// hideCostPrice menghapus cost_price dari response jika user tidak boleh
+// melihat HPP
+func hideCostPrice(r *http.Request, products []models.Product) {
+	if canSeeCostPrice(r) {
+		return
+	}
+	for i := range products {
+		products[i].CostPrice = nil
+	}
+}
+
+// exportProducts mengirim seluruh produk yang cocok dengan filter sebagai CSV
+// atau XLSX, tanpa pagination. Kolom HPP hanya disertakan jika withCostPrice.
+func (h *Producthandler) exportProducts(w http.ResponseWriter, format string, filter models.ProductFilter, withCostPrice bool) {
+	header := []string{"ID", "SKU", "Barcode", "Nama", "Kategori", "Harga", "HPP", "Stok", "Reorder Point", "Reorder Qty", "Dibuat"}
+	if !withCostPrice {
+		header = slices.Delete(header, 6, 7)
+	}
+	export := &tableExport{
+		w:        w,
+		format:   format,
+		filename: "produk",
+		title:    "Produk",
+		header:   header,
+	}
+	err := h.service.ExportProducts(filter, func(product models.Product) error {
+		sku := ""
+		if product.SKU != nil {
+			sku = *product.SKU
+		}
+		row := []interface{}{product.ID, sku, strings.Join(product.Barcodes, ", "), product.Name, product.CategoryName, rupiah(product.Price)}
+		if withCostPrice {
+			costPrice := 0
+			if product.CostPrice != nil {
+				costPrice = *product.CostPrice
+			}
+			row = append(row, rupiah(costPrice))
+		}
+		row = append(row, *product.Stock, product.ReorderPoint, product.ReorderQty, product.CreatedAt)
+		return export.WriteRow(row...)
+	})
+	export.finish(err)
+}
+
+// parseProductFilter membaca parameter query pagination, sorting dan filter produk.
+// sort menerima name, price, stock atau created_at; awalan "-" untuk urutan menurun.
+func parseProductFilter(r *http.Request) (models.ProductFilter, error) {
+	q := r.URL.Query()
+	filter := models.ProductFilter{
+		Name:       q.Get("name"),
+		CategoryID: q.Get("category_id"),
+		Cursor:     q.Get("cursor"),
+	}
+
+	if sort := q.Get("sort"); sort != "" {
+		filter.Desc = strings.HasPrefix(sort, "-")
+		filter.Sort = strings.TrimPrefix(sort, "-")
+		switch filter.Sort {
+		case "name", "price", "stock", "created_at":
+		default:
+			return filter, apperror.ErrInvalidQuery.WithMessage("sort harus salah satu dari name, price, stock, created_at")
+		}
+	}
+
+	intParams := []struct {
+		key   string
+		value **int
+	}{
+		{"min_price", &filter.MinPrice},
+		{"max_price", &filter.MaxPrice},
+		{"low_stock", &filter.LowStock},
+	}
+	for _, p := range intParams {
+		raw := q.Get(p.key)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			return filter, apperror.ErrInvalidQuery.WithMessage(p.key + " harus berupa angka yang valid")
+		}
+		*p.value = &v
+	}
+
+	if l := q.Get("limit"); l != "" {
+		v, err := strconv.Atoi(l)
+		if err != nil {
+			return filter, apperror.ErrInvalidQuery.WithMessage("limit harus berupa angka yang valid")
+		}
+		filter.Limit = v
+	}
+
+	return filter, nil
+}
+
 // parseProductFromForm parses product data from multipart form or JSON body
 func (h *Producthandler) parseProductFromForm(w http.ResponseWriter, r *http.Request) (*models.Product, error) {
 	var product models.Product
@@ -48,7 +165,7 @@ func (h *Producthandler) parseProductFromForm(w http.ResponseWriter, r *http.Req
 		// Set max upload size to 1MB
 		r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
 		if err := r.ParseMultipartForm(1 << 20); err != nil {
-			return nil, fmt.Errorf("ukuran file maksimal 1MB: %w", err)
+			return nil, apperror.ErrInvalidBody.WithMessage("ukuran file maksimal 1MB").Wrap(err)
 		}
 		defer r.MultipartForm.RemoveAll()
 
@@ -59,36 +176,67 @@ func (h *Producthandler) parseProductFromForm(w http.ResponseWriter, r *http.Req
 		if p := r.FormValue("price"); p != "" {
 			v, err := strconv.Atoi(p)
 			if err != nil {
-				return nil, fmt.Errorf("price harus berupa angka yang valid")
+				return nil, apperror.ErrInvalidBody.WithMessage("price harus berupa angka yang valid")
 			}
 			product.Price = v
 		}
+		formIntParams := []struct {
+			key   string
+			value **int
+		}{
+			{"cost_price", &product.CostPrice},
+			{"reorder_point", &product.ReorderPoint},
+			{"reorder_qty", &product.ReorderQty},
+		}
+		for _, p := range formIntParams {
+			if raw := r.FormValue(p.key); raw != "" {
+				v, err := strconv.Atoi(raw)
+				if err != nil {
+					return nil, apperror.ErrInvalidBody.WithMessage(p.key + " harus berupa angka yang valid")
+				}
+				*p.value = &v
+			}
+		}
 		if s := r.FormValue("stock"); s != "" {
 			v, err := strconv.Atoi(s)
 			if err != nil {
-				return nil, fmt.Errorf("stock harus berupa angka yang valid")
+				return nil, apperror.ErrInvalidBody.WithMessage("stock harus berupa angka yang valid")
+			}
+			product.Stock = &v
+		}
+		// sku dan barcodes yang dikirim kosong menghapus nilai lama; barcodes
+		// dipisah koma
+		if values, ok := r.MultipartForm.Value["sku"]; ok {
+			product.SKU = &values[0]
+		}
+		if values, ok := r.MultipartForm.Value["barcodes"]; ok {
+			product.Barcodes = []string{}
+			for _, code := range strings.Split(values[0], ",") {
+				if code = strings.TrimSpace(code); code != "" {
+					product.Barcodes = append(product.Barcodes, code)
+				}
 			}
-			product.Stock = v
 		}
 		product.CategoryID = r.FormValue("category_id")
+		product.StockReason = r.FormValue("stock_reason")
 
 		file, header, err := r.FormFile("picture_url")
 		if err != nil && err != http.ErrMissingFile {
 			// Real error occurred (not just missing file)
-			return nil, fmt.Errorf("gagal membaca file: %w", err)
+			return nil, apperror.ErrInvalidBody.WithMessage("gagal membaca file").Wrap(err)
 		}
 		if err == nil {
 			defer file.Close()
 
 			// Validate file size
 			if header.Size > 1<<20 {
-				return nil, fmt.Errorf("ukuran file maksimal 1MB")
+				return nil, apperror.ErrInvalidBody.WithMessage("ukuran file maksimal 1MB")
 			}
 
 			// delegate upload to service for better separation of concerns
 			url, err := h.service.UploadProductImage(r.Context(), file, header.Filename, header.Header.Get("Content-Type"))
 			if err != nil {
-				return nil, fmt.Errorf("gagal mengupload gambar: %w", err)
+				return nil, errImageUploadFailed.Wrap(err)
 			}
 			product.PictureURL = &url
 		}
@@ -96,7 +244,7 @@ func (h *Producthandler) parseProductFromForm(w http.ResponseWriter, r *http.Req
 	} else {
 		// fallback to JSON body
 		if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
-			return nil, fmt.Errorf("ada kesalahan saat mengambil data: %w", err)
+			return nil, apperror.ErrInvalidBody.Wrap(err)
 		}
 	}
 
@@ -108,14 +256,12 @@ func (h *Producthandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 
 	product, err := h.parseProductFromForm(w, r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		slog.Error(err.Error())
+		writeError(w, err)
 		return
 	}
 
-	if err := h.service.CreateProduct(product); err != nil {
-		http.Error(w, "ada kesalahan saat membuat produk", http.StatusBadRequest)
-		slog.Error(err.Error())
+	if err := h.service.CreateProduct(product, currentUser(r)); err != nil {
+		writeError(w, err)
 		return
 	}
 
@@ -128,35 +274,53 @@ func (h *Producthandler) GetProductByID(w http.ResponseWriter, r *http.Request)
 	id := chi.URLParam(r, "id")
 	product, err := h.service.GetProductByID(id)
 	if err != nil {
-		http.Error(w, "produk tidak ditemukan", http.StatusNotFound)
-		slog.Error(err.Error())
+		writeError(w, err)
 		return
 	}
+	if !canSeeCostPrice(r) {
+		product.CostPrice = nil
+	}
 
 	json.NewEncoder(w).Encode(product)
 
 }
 
+// LookupProduct mencari produk berdasarkan barcode atau SKU yang sama persis,
+// misalnya hasil scan di kasir
+func (h *Producthandler) LookupProduct(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	product, err := h.service.LookupProduct(q.Get("barcode"), q.Get("sku"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if !canSeeCostPrice(r) {
+		product.CostPrice = nil
+	}
+
+	json.NewEncoder(w).Encode(product)
+}
+
 func (h *Producthandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	id := chi.URLParam(r, "id")
 	if id == "" {
-		http.Error(w, "id tidak boleh kosong", http.StatusBadRequest)
+		writeError(w, apperror.ErrMissingID)
 		return
 	}
 
 	product, err := h.parseProductFromForm(w, r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		slog.Error(err.Error())
+		writeError(w, err)
 		return
 	}
 
 	product.ID = id
-	if err := h.service.UpdateProduct(product); err != nil {
-		http.Error(w, "ada kesalahan saat mengupdate produk", http.StatusBadRequest)
-		slog.Error(err.Error())
+	if err := h.service.UpdateProduct(product, currentUser(r)); err != nil {
+		writeError(w, err)
 		return
 	}
 
@@ -167,10 +331,9 @@ func (h *Producthandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	id := chi.URLParam(r, "id")
-	err := h.service.DeleteProduct(id)
+	err := h.service.DeleteProduct(id, currentUser(r))
 	if err != nil {
-		http.Error(w, "ada kesalahan saat menghapus produk", http.StatusBadRequest)
-		slog.Error(err.Error())
+		writeError(w, err)
 		return
 	}
 
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"labkoding.my.id/kasir-api/apperror"
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/services"
+)
+
+type PurchaseOrderHandler struct {
+	service *services.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(service *services.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{
+		service: service,
+	}
+}
+
+func (h *PurchaseOrderHandler) GetAllPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := models.PurchaseOrderFilter{
+		Status:     q.Get("status"),
+		SupplierID: q.Get("supplier_id"),
+	}
+	if filter.Status != "" && !slices.Contains(models.PurchaseOrderStatuses, filter.Status) {
+		writeError(w, apperror.ErrInvalidQuery.WithMessage("status harus salah satu dari "+strings.Join(models.PurchaseOrderStatuses, ", ")))
+		return
+	}
+
+	purchaseOrders, err := h.service.GetAllPurchaseOrders(filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, purchaseOrders)
+}
+
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var req models.PurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, apperror.ErrInvalidBody.Wrap(err))
+		return
+	}
+
+	purchaseOrder, err := h.service.CreatePurchaseOrder(req, currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, purchaseOrder)
+}
+
+func (h *PurchaseOrderHandler) GetPurchaseOrderByID(w http.ResponseWriter, r *http.Request) {
+	purchaseOrder, err := h.service.GetPurchaseOrderByID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, purchaseOrder)
+}
+
+func (h *PurchaseOrderHandler) UpdatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var req models.PurchaseOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, apperror.ErrInvalidBody.Wrap(err))
+		return
+	}
+
+	purchaseOrder, err := h.service.UpdatePurchaseOrder(chi.URLParam(r, "id"), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, purchaseOrder)
+}
+
+func (h *PurchaseOrderHandler) OrderPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	purchaseOrder, err := h.service.OrderPurchaseOrder(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, purchaseOrder)
+}
+
+func (h *PurchaseOrderHandler) CancelPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	purchaseOrder, err := h.service.CancelPurchaseOrder(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, purchaseOrder)
+}
+
+func (h *PurchaseOrderHandler) ReceiveGoods(w http.ResponseWriter, r *http.Request) {
+	var req models.GoodsReceiptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, apperror.ErrInvalidBody.Wrap(err))
+		return
+	}
+
+	receipt, err := h.service.ReceiveGoods(chi.URLParam(r, "id"), req, currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, receipt)
+}
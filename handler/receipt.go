@@ -0,0 +1,336 @@
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-pdf/fpdf"
+	"labkoding.my.id/kasir-api/apperror"
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/services"
+)
+
+// receiptColumns adalah jumlah karakter per baris font standar printer
+// thermal (Font A, 12 dot) untuk setiap lebar kertas
+var receiptColumns = map[int]int{
+	models.PaperWidth58: 32,
+	models.PaperWidth80: 48,
+}
+
+var paymentMethodLabels = map[string]string{
+	models.PaymentMethodCash:      "Tunai",
+	models.PaymentMethodQRIS:      "QRIS",
+	models.PaymentMethodDebitCard: "Kartu Debit",
+	models.PaymentMethodEWallet:   "E-Wallet",
+}
+
+type ReceiptHandler struct {
+	service *services.ReceiptService
+}
+
+func NewReceiptHandler(service *services.ReceiptService) *ReceiptHandler {
+	return &ReceiptHandler{
+		service: service,
+	}
+}
+
+func (h *ReceiptHandler) GetReceipt(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = models.ReceiptFormatText
+	}
+	if !slices.Contains(models.ReceiptFormats, format) {
+		writeError(w, apperror.ErrInvalidQuery.WithMessage("format harus salah satu dari "+strings.Join(models.ReceiptFormats, ", ")))
+		return
+	}
+	paperWidth := 0
+	if raw := q.Get("width"); raw != "" {
+		v, err := strconv.Atoi(raw)
+		if err != nil {
+			writeError(w, apperror.ErrInvalidQuery.WithMessage("width harus 58 atau 80"))
+			return
+		}
+		paperWidth = v
+	}
+
+	receipt, err := h.service.GetReceipt(chi.URLParam(r, "id"), paperWidth)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	// struk dirender ke buffer dulu supaya error masih bisa dikirim sebagai JSON
+	lines := layoutReceipt(receipt)
+	var buf bytes.Buffer
+	filename := "struk-" + receipt.Transaction.ID
+	switch format {
+	case models.ReceiptFormatESCPOS:
+		renderESCPOS(&buf, lines)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".bin"))
+	case models.ReceiptFormatPDF:
+		if err := renderReceiptPDF(&buf, lines, receipt.PaperWidth); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename+".pdf"))
+	default:
+		renderReceiptText(&buf, lines, receiptColumns[receipt.PaperWidth])
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+
+	w.Write(buf.Bytes())
+}
+
+// receiptLine adalah satu baris struk yang panjangnya sudah sesuai lebar
+// kertas
+type receiptLine struct {
+	text   string
+	center bool
+	bold   bool
+}
+
+// receiptLayout menyusun baris struk untuk sejumlah kolom karakter
+type receiptLayout struct {
+	columns int
+	lines   []receiptLine
+}
+
+func (l *receiptLayout) add(text string, center, bold bool) {
+	for _, line := range wrapText(text, l.columns) {
+		l.lines = append(l.lines, receiptLine{text: line, center: center, bold: bold})
+	}
+}
+
+func (l *receiptLayout) separator() {
+	l.lines = append(l.lines, receiptLine{text: strings.Repeat("-", l.columns)})
+}
+
+// amount menulis label di kiri dan nilai di kanan. Label yang terlalu panjang
+// ditulis di baris sendiri.
+func (l *receiptLayout) amount(label string, value int, bold bool) {
+	right := formatRupiah(value)
+	space := l.columns - utf8.RuneCountInString(label) - utf8.RuneCountInString(right)
+	if space < 1 {
+		l.add(label, false, bold)
+		label, space = "", l.columns-utf8.RuneCountInString(right)
+	}
+	l.lines = append(l.lines, receiptLine{text: label + strings.Repeat(" ", space) + right, bold: bold})
+}
+
+func layoutReceipt(receipt *models.Receipt) []receiptLine {
+	l := &receiptLayout{columns: receiptColumns[receipt.PaperWidth]}
+	config := receipt.Config
+	transaction := receipt.Transaction
+
+	if config.StoreName != "" {
+		l.add(config.StoreName, true, true)
+	}
+	if config.StoreAddress != "" {
+		l.add(config.StoreAddress, true, false)
+	}
+	if config.NPWP != "" {
+		l.add("NPWP: "+config.NPWP, true, false)
+	}
+	l.separator()
+
+	l.add("No: "+transaction.ID, false, false)
+	l.add("Tanggal: "+transaction.CreatedAt.Format("02-01-2006 15:04"), false, false)
+	if receipt.CashierUsername != "" {
+		l.add("Kasir: "+receipt.CashierUsername, false, false)
+	}
+	l.separator()
+
+	for _, detail := range transaction.Details {
+		l.add(detail.ProductName, false, false)
+		l.amount(fmt.Sprintf("  %d x %s", detail.Quantity, formatRupiah(detail.GrossAmount/detail.Quantity)), detail.GrossAmount, false)
+		if detail.DiscountAmount > 0 {
+			l.amount("  Diskon", -detail.DiscountAmount, false)
+		}
+	}
+	l.separator()
+
+	l.amount("Subtotal", transaction.GrossAmount, false)
+	if transaction.DiscountAmount > 0 {
+		l.amount("Total Diskon", -transaction.DiscountAmount, false)
+	}
+	if transaction.ServiceChargeAmount > 0 {
+		l.amount("Service Charge", transaction.ServiceChargeAmount, false)
+	}
+	if transaction.TaxAmount > 0 {
+		label := "PPN " + strconv.FormatFloat(transaction.TaxRate, 'f', -1, 64) + "%"
+		if transaction.TaxInclusive {
+			label += " (termasuk)"
+		}
+		l.amount(label, transaction.TaxAmount, false)
+	}
+	l.amount("TOTAL", transaction.TotalAmount, true)
+	for _, payment := range transaction.Payments {
+		label, ok := paymentMethodLabels[payment.Method]
+		if !ok {
+			label = payment.Method
+		}
+		l.amount(label, payment.Tendered, false)
+	}
+	if transaction.ChangeAmount > 0 {
+		l.amount("Kembalian", transaction.ChangeAmount, false)
+	}
+
+	if transaction.Status == models.TransactionStatusVoided {
+		l.separator()
+		l.add("*** TRANSAKSI DIBATALKAN ***", true, true)
+		if transaction.VoidReason != nil {
+			l.add(*transaction.VoidReason, true, false)
+		}
+	}
+	for _, refund := range transaction.Refunds {
+		if refund.Type == models.RefundTypeRefund {
+			l.amount("Refund "+refund.CreatedAt.Format("02-01 15:04"), -refund.TotalAmount, false)
+		}
+	}
+
+	if config.Footer != "" {
+		l.separator()
+		for _, line := range strings.Split(config.Footer, "\n") {
+			l.add(line, true, false)
+		}
+	}
+
+	return l.lines
+}
+
+// wrapText memecah teks per kata agar tidak melebihi width karakter. Kata
+// yang lebih panjang dari width dipotong paksa.
+func wrapText(text string, width int) []string {
+	lines := make([]string, 0, 1)
+	current := ""
+	for _, word := range strings.Fields(text) {
+		for utf8.RuneCountInString(word) > width {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= width:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" || len(lines) == 0 {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// centerText menambahkan spasi di kiri agar teks berada di tengah
+func centerText(text string, columns int) string {
+	pad := (columns - utf8.RuneCountInString(text)) / 2
+	if pad <= 0 {
+		return text
+	}
+	return strings.Repeat(" ", pad) + text
+}
+
+func renderReceiptText(buf *bytes.Buffer, lines []receiptLine, columns int) {
+	for _, line := range lines {
+		if line.center {
+			buf.WriteString(centerText(line.text, columns))
+		} else {
+			buf.WriteString(line.text)
+		}
+		buf.WriteByte('\n')
+	}
+}
+
+// Perintah ESC/POS yang dipakai
+var (
+	escposInit        = []byte{0x1b, '@'}
+	escposAlignLeft   = []byte{0x1b, 'a', 0}
+	escposAlignCenter = []byte{0x1b, 'a', 1}
+	escposBoldOn      = []byte{0x1b, 'E', 1}
+	escposBoldOff     = []byte{0x1b, 'E', 0}
+	escposFeed        = []byte{0x1b, 'd', 4}
+	escposCut         = []byte{0x1d, 'V', 66, 0}
+)
+
+// renderESCPOS menulis struk sebagai perintah ESC/POS. Perataan tengah dan
+// huruf tebal memakai perintah printer; karakter di luar ASCII diganti "?"
+// karena code page default printer berbeda-beda.
+func renderESCPOS(buf *bytes.Buffer, lines []receiptLine) {
+	buf.Write(escposInit)
+	for _, line := range lines {
+		if line.center {
+			buf.Write(escposAlignCenter)
+		} else {
+			buf.Write(escposAlignLeft)
+		}
+		if line.bold {
+			buf.Write(escposBoldOn)
+		}
+		for _, r := range line.text {
+			if r < 0x20 || r > 0x7e {
+				r = '?'
+			}
+			buf.WriteByte(byte(r))
+		}
+		buf.WriteByte('\n')
+		if line.bold {
+			buf.Write(escposBoldOff)
+		}
+	}
+	buf.Write(escposAlignLeft)
+	buf.Write(escposFeed)
+	buf.Write(escposCut)
+}
+
+// renderReceiptPDF menulis struk sebagai PDF satu halaman selebar kertas
+// thermal dengan tinggi mengikuti jumlah baris. Ukuran font Courier dihitung
+// agar jumlah kolom sama dengan printer thermal.
+func renderReceiptPDF(buf *bytes.Buffer, lines []receiptLine, paperWidth int) error {
+	const margin = 3.0
+	const ptToMM = 25.4 / 72
+	// lebar satu karakter Courier adalah 0,6 kali ukuran font
+	columns := receiptColumns[paperWidth]
+	charWidth := (float64(paperWidth) - 2*margin) / float64(columns)
+	fontSize := charWidth / (0.6 * ptToMM)
+	lineHeight := fontSize * ptToMM * 1.25
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		UnitStr: "mm",
+		Size:    fpdf.SizeType{Wd: float64(paperWidth), Ht: 2*margin + lineHeight*float64(len(lines))},
+	})
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+	translate := pdf.UnicodeTranslatorFromDescriptor("")
+
+	for _, line := range lines {
+		style, align := "", "L"
+		if line.bold {
+			style = "B"
+		}
+		if line.center {
+			align = "C"
+		}
+		pdf.SetFont("Courier", style, fontSize)
+		pdf.CellFormat(0, lineHeight, translate(line.text), "", 1, align, false, 0, "")
+	}
+
+	return pdf.Output(buf)
+}
@@ -2,8 +2,14 @@ package handler
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 
+	"labkoding.my.id/kasir-api/apperror"
+	"labkoding.my.id/kasir-api/models"
 	"labkoding.my.id/kasir-api/services"
 )
 
@@ -17,10 +23,41 @@ func NewReportHandler(service *services.ReportService) *ReportHandler {
 	}
 }
 
+// parseTop membaca query top untuk jumlah produk dan kategori terlaris; 0
+// berarti memakai nilai default
+func parseTop(r *http.Request) (int, error) {
+	raw := r.URL.Query().Get("top")
+	if raw == "" {
+		return 0, nil
+	}
+	top, err := strconv.Atoi(raw)
+	if err != nil || top < 1 {
+		return 0, apperror.ErrInvalidQuery.WithMessage("top harus berupa angka lebih dari 0")
+	}
+	return top, nil
+}
+
 func (h *ReportHandler) TodayReport(w http.ResponseWriter, r *http.Request) {
-	report, err := h.service.TodayReport()
+	top, err := parseTop(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	format, err := parseExportFormat(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+
+	report, err := h.service.TodayReport(top)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if format != "" {
+		exportReport(w, format, "laporan-hari-ini", report)
 		return
 	}
 
@@ -31,13 +68,147 @@ func (h *ReportHandler) TodayReport(w http.ResponseWriter, r *http.Request) {
 func (h *ReportHandler) RangeReport(w http.ResponseWriter, r *http.Request) {
 	startDate := r.URL.Query().Get("start_date")
 	endDate := r.URL.Query().Get("end_date")
+	top, err := parseTop(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
 
-	report, err := h.service.RangeReport(startDate, endDate)
+	format, err := parseExportFormat(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+
+	report, err := h.service.RangeReport(startDate, endDate, top)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if format != "" {
+		exportReport(w, format, "laporan-"+startDate+"_"+endDate, report)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *ReportHandler) TaxReport(w http.ResponseWriter, r *http.Request) {
+	startDate := r.URL.Query().Get("start_date")
+	endDate := r.URL.Query().Get("end_date")
+
+	report, err := h.service.TaxReport(startDate, endDate)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(report)
 }
+
+func (h *ReportHandler) TimeSeriesReport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	granularity := q.Get("granularity")
+	if !slices.Contains(models.Granularities, granularity) {
+		writeError(w, apperror.ErrInvalidQuery.WithMessage("granularity harus salah satu dari "+strings.Join(models.Granularities, ", ")))
+		return
+	}
+
+	report, err := h.service.TimeSeries(granularity, q.Get("start_date"), q.Get("end_date"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// exportReport menulis laporan sebagai CSV atau XLSX. Setiap bagian laporan
+// menjadi tabel tersendiri (sheet tersendiri pada XLSX).
+func exportReport(w http.ResponseWriter, format, filename string, report models.Report) {
+	writer, err := newExportWriter(w, format, filename)
+	if err != nil {
+		w.Header().Del("Content-Disposition")
+		writeError(w, err)
+		return
+	}
+	if err := writeReportTables(writer, report); err != nil {
+		failExport(w, writer, filename, err)
+		return
+	}
+	if err := writer.Close(); err != nil {
+		slog.Error("export_failed", slog.String("file", filename), slog.String("error", err.Error()))
+	}
+}
+
+func writeReportTables(writer exportWriter, report models.Report) error {
+	if err := writer.StartTable("Ringkasan", []string{"Keterangan", "Nilai"}); err != nil {
+		return err
+	}
+	summary := []struct {
+		label string
+		value interface{}
+	}{
+		{"Total Pendapatan", rupiah(report.TotalRevenue)},
+		{"Total Refund", rupiah(report.TotalRefunds)},
+		{"Jumlah Transaksi", report.TotalTransactions},
+		{"Penjualan Bersih", rupiah(report.Margin.NetSales)},
+		{"HPP", rupiah(report.Margin.Cogs)},
+		{"Laba Kotor", rupiah(report.Margin.GrossProfit)},
+		{"Margin Kotor", percent(report.Margin.GrossMarginPercent)},
+	}
+	for _, row := range summary {
+		if err := writer.WriteRow(row.label, row.value); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.StartTable("Produk Terlaris", []string{"Peringkat", "ID Produk", "Nama", "Qty Terjual", "Pendapatan", "Share Qty", "Share Pendapatan"}); err != nil {
+		return err
+	}
+	for _, p := range report.BestSellingProducts {
+		if err := writer.WriteRow(p.Rank, p.ProductID, p.Name, p.QtySold, rupiah(p.Revenue), percent(p.QtySharePercent), percent(p.RevenueSharePercent)); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.StartTable("Kategori Terlaris", []string{"Peringkat", "ID Kategori", "Nama", "Qty Terjual", "Pendapatan", "Share Qty", "Share Pendapatan"}); err != nil {
+		return err
+	}
+	for _, c := range report.BestSellingCategories {
+		if err := writer.WriteRow(c.Rank, c.CategoryID, c.Name, c.QtySold, rupiah(c.Revenue), percent(c.QtySharePercent), percent(c.RevenueSharePercent)); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.StartTable("Metode Pembayaran", []string{"Metode", "Total", "Jumlah Transaksi"}); err != nil {
+		return err
+	}
+	for _, m := range report.PaymentMethods {
+		if err := writer.WriteRow(m.Method, rupiah(m.TotalAmount), m.TotalTransactions); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.StartTable("Margin Produk", []string{"ID Produk", "Nama", "Qty Terjual", "Penjualan Bersih", "HPP", "Laba Kotor", "Margin Kotor"}); err != nil {
+		return err
+	}
+	for _, p := range report.ProductMargins {
+		if err := writer.WriteRow(p.ProductID, p.ProductName, p.QtySold, rupiah(p.NetSales), rupiah(p.Cogs), rupiah(p.GrossProfit), percent(p.GrossMarginPercent)); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.StartTable("Margin Kategori", []string{"ID Kategori", "Nama", "Qty Terjual", "Penjualan Bersih", "HPP", "Laba Kotor", "Margin Kotor"}); err != nil {
+		return err
+	}
+	for _, c := range report.CategoryMargins {
+		if err := writer.WriteRow(c.CategoryID, c.CategoryName, c.QtySold, rupiah(c.NetSales), rupiah(c.Cogs), rupiah(c.GrossProfit), percent(c.GrossMarginPercent)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"labkoding.my.id/kasir-api/apperror"
+	"labkoding.my.id/kasir-api/models"
+)
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError menampilkan error dalam bentuk models.ErrorResponse. Error yang
+// bukan apperror dicatat ke log dan ditampilkan sebagai INTERNAL_ERROR.
+func writeError(w http.ResponseWriter, err error) {
+	appErr := apperror.From(err)
+	if appErr.Status >= http.StatusInternalServerError {
+		slog.Error(err.Error())
+	}
+
+	writeJSON(w, appErr.Status, models.ErrorResponse{
+		Error: models.ErrorBody{
+			Code:    appErr.Code,
+			Message: appErr.Message,
+			Details: appErr.Details,
+		},
+	})
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"labkoding.my.id/kasir-api/apperror"
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/services"
+)
+
+type ShiftHandler struct {
+	service *services.ShiftService
+}
+
+func NewShiftHandler(service *services.ShiftService) *ShiftHandler {
+	return &ShiftHandler{
+		service: service,
+	}
+}
+
+func (h *ShiftHandler) GetAllShifts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := models.ShiftFilter{
+		Status:    q.Get("status"),
+		CashierID: q.Get("cashier_id"),
+	}
+
+	shifts, err := h.service.GetAllShifts(filter, currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, shifts)
+}
+
+func (h *ShiftHandler) OpenShift(w http.ResponseWriter, r *http.Request) {
+	var req models.OpenShiftRequest
+	// body boleh kosong jika shift dibuka tanpa uang modal
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, apperror.ErrInvalidBody.Wrap(err))
+		return
+	}
+
+	report, err := h.service.OpenShift(req, currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, report)
+}
+
+func (h *ShiftHandler) GetCurrentShift(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.GetCurrentShift(currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (h *ShiftHandler) GetShiftReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.GetShiftReport(chi.URLParam(r, "id"), currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (h *ShiftHandler) AddCashMovement(w http.ResponseWriter, r *http.Request) {
+	var req models.CashMovementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, apperror.ErrInvalidBody.Wrap(err))
+		return
+	}
+
+	report, err := h.service.AddCashMovement(chi.URLParam(r, "id"), req, currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, report)
+}
+
+func (h *ShiftHandler) CloseShift(w http.ResponseWriter, r *http.Request) {
+	var req models.CloseShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, apperror.ErrInvalidBody.Wrap(err))
+		return
+	}
+
+	report, err := h.service.CloseShift(chi.URLParam(r, "id"), req, currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"labkoding.my.id/kasir-api/apperror"
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/services"
+)
+
+type StockHandler struct {
+	service *services.StockService
+}
+
+func NewStockHandler(service *services.StockService) *StockHandler {
+	return &StockHandler{
+		service: service,
+	}
+}
+
+func (h *StockHandler) GetStockMovements(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := models.StockMovementFilter{
+		ProductID: chi.URLParam(r, "id"),
+		Type:      q.Get("type"),
+	}
+
+	if filter.Type != "" && !slices.Contains(models.StockMovementTypes, filter.Type) {
+		writeError(w, apperror.ErrInvalidQuery.WithMessage("type harus salah satu dari "+strings.Join(models.StockMovementTypes, ", ")))
+		return
+	}
+	if p := q.Get("page"); p != "" {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			writeError(w, apperror.ErrInvalidQuery.WithMessage("page harus berupa angka yang valid"))
+			return
+		}
+		filter.Page = v
+	}
+	if l := q.Get("limit"); l != "" {
+		v, err := strconv.Atoi(l)
+		if err != nil {
+			writeError(w, apperror.ErrInvalidQuery.WithMessage("limit harus berupa angka yang valid"))
+			return
+		}
+		filter.Limit = v
+	}
+
+	movements, err := h.service.GetStockMovements(filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, movements)
+}
+
+func (h *StockHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	var req models.StockAdjustmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, apperror.ErrInvalidBody.Wrap(err))
+		return
+	}
+
+	movement, err := h.service.AdjustStock(chi.URLParam(r, "id"), req, currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, movement)
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"labkoding.my.id/kasir-api/apperror"
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/services"
+)
+
+type StockTakeHandler struct {
+	service *services.StockTakeService
+}
+
+func NewStockTakeHandler(service *services.StockTakeService) *StockTakeHandler {
+	return &StockTakeHandler{
+		service: service,
+	}
+}
+
+func (h *StockTakeHandler) GetAllStockTakes(w http.ResponseWriter, r *http.Request) {
+	stockTakes, err := h.service.GetAllStockTakes(r.URL.Query().Get("status"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, stockTakes)
+}
+
+func (h *StockTakeHandler) CreateStockTake(w http.ResponseWriter, r *http.Request) {
+	var req models.StockTakeRequest
+	// body boleh kosong jika sesi tidak diberi catatan
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		writeError(w, apperror.ErrInvalidBody.Wrap(err))
+		return
+	}
+
+	stockTake, err := h.service.CreateStockTake(req, currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, stockTake)
+}
+
+func (h *StockTakeHandler) GetStockTakeReport(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.GetStockTakeReport(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (h *StockTakeHandler) SubmitCounts(w http.ResponseWriter, r *http.Request) {
+	var req models.StockTakeCountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, apperror.ErrInvalidBody.Wrap(err))
+		return
+	}
+
+	report, err := h.service.SubmitCounts(chi.URLParam(r, "id"), req, currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (h *StockTakeHandler) FinalizeStockTake(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.FinalizeStockTake(chi.URLParam(r, "id"), currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (h *StockTakeHandler) CancelStockTake(w http.ResponseWriter, r *http.Request) {
+	report, err := h.service.CancelStockTake(chi.URLParam(r, "id"), currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
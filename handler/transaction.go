@@ -3,7 +3,10 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
 
+	"github.com/go-chi/chi/v5"
+	"labkoding.my.id/kasir-api/apperror"
 	"labkoding.my.id/kasir-api/models"
 	"labkoding.my.id/kasir-api/services"
 )
@@ -23,16 +26,154 @@ func (h *TransactionHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	var req models.CheckoutRequest
 	err := json.NewDecoder(r.Body).Decode(&req)
 	if err != nil {
-		http.Error(w, "ada kesalahan saat mengambil data", http.StatusBadRequest)
+		writeError(w, apperror.ErrInvalidBody.Wrap(err))
 		return
 	}
 
-	transaction, err := h.service.Checkout(req.Items)
+	req.IdempotencyKey = r.Header.Get("Idempotency-Key")
+	req.Cashier = currentUser(r)
+	transaction, replayed, err := h.service.Checkout(req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	if replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+	json.NewEncoder(w).Encode(transaction)
+}
+
+func (h *TransactionHandler) GetAllTransactions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	filter, err := parseTransactionFilter(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	format, err := parseExportFormat(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if format != "" {
+		h.exportTransactions(w, format, filter)
+		return
+	}
+
+	transactions, err := h.service.GetAllTransactions(filter)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(transactions)
+}
+
+// exportTransactions mengirim seluruh transaksi yang cocok dengan filter
+// sebagai CSV atau XLSX, tanpa pagination
+func (h *TransactionHandler) exportTransactions(w http.ResponseWriter, format string, filter models.TransactionFilter) {
+	export := &tableExport{
+		w:        w,
+		format:   format,
+		filename: "transaksi",
+		title:    "Transaksi",
+		header:   []string{"ID", "Tanggal", "Kasir", "Status", "Jumlah Item", "Bruto", "Diskon", "Service Charge", "PPN", "Total", "Dibayar", "Kembalian", "Metode Pembayaran", "Alasan Void"},
+	}
+	err := h.service.ExportTransactions(filter, func(row models.TransactionExportRow) error {
+		return export.WriteRow(row.ID, row.CreatedAt, row.CashierUsername, row.Status, row.ItemCount, rupiah(row.GrossAmount), rupiah(row.DiscountAmount), rupiah(row.ServiceChargeAmount), rupiah(row.TaxAmount), rupiah(row.TotalAmount), rupiah(row.PaidAmount), rupiah(row.ChangeAmount), row.PaymentMethods, row.VoidReason)
+	})
+	export.finish(err)
+}
+
+func (h *TransactionHandler) GetTransactionByID(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+	transaction, err := h.service.GetTransactionByID(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
 	json.NewEncoder(w).Encode(transaction)
 }
+
+// parseTransactionFilter membaca parameter query pagination dan filter transaksi
+func parseTransactionFilter(r *http.Request) (models.TransactionFilter, error) {
+	q := r.URL.Query()
+	filter := models.TransactionFilter{
+		StartDate: q.Get("start_date"),
+		EndDate:   q.Get("end_date"),
+		ProductID: q.Get("product_id"),
+		ShiftID:   q.Get("shift_id"),
+	}
+
+	if p := q.Get("page"); p != "" {
+		v, err := strconv.Atoi(p)
+		if err != nil {
+			return filter, apperror.ErrInvalidQuery.WithMessage("page harus berupa angka yang valid")
+		}
+		filter.Page = v
+	}
+	if l := q.Get("limit"); l != "" {
+		v, err := strconv.Atoi(l)
+		if err != nil {
+			return filter, apperror.ErrInvalidQuery.WithMessage("limit harus berupa angka yang valid")
+		}
+		filter.Limit = v
+	}
+	if m := q.Get("min_amount"); m != "" {
+		v, err := strconv.Atoi(m)
+		if err != nil {
+			return filter, apperror.ErrInvalidQuery.WithMessage("min_amount harus berupa angka yang valid")
+		}
+		filter.MinAmount = &v
+	}
+	if m := q.Get("max_amount"); m != "" {
+		v, err := strconv.Atoi(m)
+		if err != nil {
+			return filter, apperror.ErrInvalidQuery.WithMessage("max_amount harus berupa angka yang valid")
+		}
+		filter.MaxAmount = &v
+	}
+
+	return filter, nil
+}
+
+func (h *TransactionHandler) VoidTransaction(w http.ResponseWriter, r *http.Request) {
+	var req models.VoidRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, apperror.ErrInvalidBody.Wrap(err))
+		return
+	}
+
+	refund, err := h.service.VoidTransaction(chi.URLParam(r, "id"), req, currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(refund)
+}
+
+func (h *TransactionHandler) RefundTransaction(w http.ResponseWriter, r *http.Request) {
+	var req models.RefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, apperror.ErrInvalidBody.Wrap(err))
+		return
+	}
+
+	refund, err := h.service.RefundTransaction(chi.URLParam(r, "id"), req, currentUser(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(refund)
+}
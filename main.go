@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -18,7 +19,10 @@ import (
 	"github.com/spf13/viper"
 	"labkoding.my.id/kasir-api/database"
 	"labkoding.my.id/kasir-api/external"
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/repositories"
 	"labkoding.my.id/kasir-api/router"
+	"labkoding.my.id/kasir-api/services"
 )
 
 type Config struct {
@@ -29,11 +33,48 @@ type Config struct {
 	AccessKeyID     string `mapstructure:"ACCESS_KEY_ID"`
 	SecretAccessKey string `mapstructure:"SECRET_ACCESS_KEY"`
 	PublicEndpoint  string `mapstructure:"PUBLIC_ENDPOINT"`
+
+	TaxRate           float64 `mapstructure:"TAX_RATE"`
+	TaxInclusive      bool    `mapstructure:"TAX_INCLUSIVE"`
+	ServiceChargeRate float64 `mapstructure:"SERVICE_CHARGE_RATE"`
+	CostMethod        string  `mapstructure:"COST_METHOD"`
+	StoreTimezone     string  `mapstructure:"STORE_TIMEZONE"`
+
+	JWTSecret          string        `mapstructure:"JWT_SECRET"`
+	JWTTTL             time.Duration `mapstructure:"JWT_TTL"`
+	CORSAllowedOrigins []string      `mapstructure:"CORS_ALLOWED_ORIGINS"`
+	OwnerUsername      string        `mapstructure:"OWNER_USERNAME"`
+	OwnerPassword      string        `mapstructure:"OWNER_PASSWORD"`
+
+	LowStockNotifier   string   `mapstructure:"LOW_STOCK_NOTIFIER"`
+	LowStockWebhookURL string   `mapstructure:"LOW_STOCK_WEBHOOK_URL"`
+	LowStockEmailTo    []string `mapstructure:"LOW_STOCK_EMAIL_TO"`
+	SMTPHost           string   `mapstructure:"SMTP_HOST"`
+	SMTPPort           int      `mapstructure:"SMTP_PORT"`
+	SMTPFrom           string   `mapstructure:"SMTP_FROM"`
+
+	StoreName         string `mapstructure:"STORE_NAME"`
+	StoreAddress      string `mapstructure:"STORE_ADDRESS"`
+	StoreNPWP         string `mapstructure:"STORE_NPWP"`
+	ReceiptFooter     string `mapstructure:"RECEIPT_FOOTER"`
+	ReceiptPaperWidth int    `mapstructure:"RECEIPT_PAPER_WIDTH"`
 }
 
 func main() {
 	viper.AutomaticEnv()
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.SetDefault("TAX_RATE", 11)
+	viper.SetDefault("JWT_TTL", "12h")
+	viper.SetDefault("COST_METHOD", models.CostMethodAverage)
+	viper.SetDefault("STORE_TIMEZONE", "Asia/Jakarta")
+	viper.SetDefault("CORS_ALLOWED_ORIGINS", "http://localhost:3000")
+	viper.SetDefault("LOW_STOCK_NOTIFIER", models.NotifierLog)
+	viper.SetDefault("SMTP_HOST", "localhost")
+	viper.SetDefault("SMTP_PORT", 1025)
+	viper.SetDefault("SMTP_FROM", "kasir@localhost")
+	viper.SetDefault("STORE_NAME", "Kasir")
+	viper.SetDefault("RECEIPT_FOOTER", "Terima kasih atas kunjungan Anda")
+	viper.SetDefault("RECEIPT_PAPER_WIDTH", models.PaperWidth58)
 
 	if _, err := os.Stat(".env"); err == nil {
 		viper.SetConfigFile(".env")
@@ -48,6 +89,57 @@ func main() {
 		AccessKeyID:     viper.GetString("ACCESS_KEY_ID"),
 		SecretAccessKey: viper.GetString("SECRET_ACCESS_KEY"),
 		PublicEndpoint:  viper.GetString("PUBLIC_ENDPOINT"),
+
+		TaxRate:           viper.GetFloat64("TAX_RATE"),
+		TaxInclusive:      viper.GetBool("TAX_INCLUSIVE"),
+		ServiceChargeRate: viper.GetFloat64("SERVICE_CHARGE_RATE"),
+		CostMethod:        viper.GetString("COST_METHOD"),
+		StoreTimezone:     viper.GetString("STORE_TIMEZONE"),
+
+		JWTSecret:          viper.GetString("JWT_SECRET"),
+		JWTTTL:             viper.GetDuration("JWT_TTL"),
+		CORSAllowedOrigins: strings.Split(viper.GetString("CORS_ALLOWED_ORIGINS"), ","),
+		OwnerUsername:      viper.GetString("OWNER_USERNAME"),
+		OwnerPassword:      viper.GetString("OWNER_PASSWORD"),
+
+		LowStockNotifier:   viper.GetString("LOW_STOCK_NOTIFIER"),
+		LowStockWebhookURL: viper.GetString("LOW_STOCK_WEBHOOK_URL"),
+		LowStockEmailTo:    strings.FieldsFunc(viper.GetString("LOW_STOCK_EMAIL_TO"), func(r rune) bool { return r == ',' }),
+		SMTPHost:           viper.GetString("SMTP_HOST"),
+		SMTPPort:           viper.GetInt("SMTP_PORT"),
+		SMTPFrom:           viper.GetString("SMTP_FROM"),
+
+		StoreName:    viper.GetString("STORE_NAME"),
+		StoreAddress: viper.GetString("STORE_ADDRESS"),
+		StoreNPWP:    viper.GetString("STORE_NPWP"),
+		// footer beberapa baris bisa ditulis dengan \n di file .env
+		ReceiptFooter:     strings.ReplaceAll(viper.GetString("RECEIPT_FOOTER"), `\n`, "\n"),
+		ReceiptPaperWidth: viper.GetInt("RECEIPT_PAPER_WIDTH"),
+	}
+	if len(config.JWTSecret) < 32 {
+		log.Fatal("JWT_SECRET wajib diisi minimal 32 karakter")
+	}
+	if !slices.Contains(models.CostMethods, config.CostMethod) {
+		log.Fatal("COST_METHOD harus salah satu dari ", strings.Join(models.CostMethods, ", "))
+	}
+	// nama zona waktu IANA juga dipakai di query Postgres, jadi "Local" tidak diterima
+	storeLocation, err := time.LoadLocation(config.StoreTimezone)
+	if err != nil || storeLocation == time.Local {
+		log.Fatal("STORE_TIMEZONE harus berupa nama zona waktu IANA, contoh Asia/Jakarta")
+	}
+	if config.ReceiptPaperWidth != models.PaperWidth58 && config.ReceiptPaperWidth != models.PaperWidth80 {
+		log.Fatal("RECEIPT_PAPER_WIDTH harus 58 atau 80")
+	}
+	lowStockNotifier, err := newLowStockNotifier(models.NotifierConfig{
+		Type:       config.LowStockNotifier,
+		WebhookURL: config.LowStockWebhookURL,
+		SMTPHost:   config.SMTPHost,
+		SMTPPort:   config.SMTPPort,
+		SMTPFrom:   config.SMTPFrom,
+		EmailTo:    config.LowStockEmailTo,
+	})
+	if err != nil {
+		log.Fatal(err)
 	}
 	db, err := database.InitDB(config.DBConn)
 	if err != nil {
@@ -55,6 +147,17 @@ func main() {
 	}
 	defer db.Close()
 
+	if config.OwnerUsername != "" {
+		userService := services.NewUserService(repositories.NewUserRepository(db))
+		created, err := userService.EnsureOwner(config.OwnerUsername, config.OwnerPassword)
+		if err != nil {
+			log.Fatal("Failed to create owner account:", err)
+		}
+		if created {
+			fmt.Println("Akun owner dibuat:", config.OwnerUsername)
+		}
+	}
+
 	err = external.InitStorage(config.BucketName, config.AccessKeyID, config.SecretAccessKey, config.AccountID, config.PublicEndpoint)
 	if err != nil {
 		log.Fatal("Failed to initialize storage:", err)
@@ -93,10 +196,10 @@ func main() {
 		})
 	})
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins:   []string{"https://*", "http://*"},
+		AllowedOrigins:   config.CORSAllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "Idempotency-Key"},
+		ExposedHeaders:   []string{"Link", "Idempotent-Replayed", "Content-Disposition"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
@@ -105,7 +208,27 @@ func main() {
 		w.Write([]byte("welcome"))
 	})
 
-	appRouter := router.NewRouter(db, r)
+	appRouter := router.NewRouter(db, r, router.Config{
+		Tax: models.TaxConfig{
+			Rate:              config.TaxRate,
+			Inclusive:         config.TaxInclusive,
+			ServiceChargeRate: config.ServiceChargeRate,
+		},
+		Auth: models.AuthConfig{
+			Secret:   config.JWTSecret,
+			TokenTTL: config.JWTTTL,
+		},
+		CostMethod:       config.CostMethod,
+		LowStockNotifier: lowStockNotifier,
+		Location:         storeLocation,
+		Receipt: models.ReceiptConfig{
+			StoreName:    config.StoreName,
+			StoreAddress: config.StoreAddress,
+			NPWP:         config.StoreNPWP,
+			Footer:       config.ReceiptFooter,
+			PaperWidth:   config.ReceiptPaperWidth,
+		},
+	})
 	appRouter.RegisterAllRoutes()
 
 	addr := "0.0.0.0:" + config.Port
@@ -116,3 +239,23 @@ func main() {
 		fmt.Println("gagal running server", err)
 	}
 }
+
+// newLowStockNotifier memilih kanal notifikasi stok menipis sesuai LOW_STOCK_NOTIFIER
+func newLowStockNotifier(config models.NotifierConfig) (services.LowStockNotifier, error) {
+	switch config.Type {
+	case models.NotifierLog:
+		return external.NewLogNotifier(), nil
+	case models.NotifierWebhook:
+		if config.WebhookURL == "" {
+			return nil, fmt.Errorf("LOW_STOCK_WEBHOOK_URL wajib diisi untuk notifier webhook")
+		}
+		return external.NewWebhookNotifier(config.WebhookURL), nil
+	case models.NotifierEmail:
+		if len(config.EmailTo) == 0 {
+			return nil, fmt.Errorf("LOW_STOCK_EMAIL_TO wajib diisi untuk notifier email")
+		}
+		return external.NewEmailNotifier(config.SMTPHost, config.SMTPPort, config.SMTPFrom, config.EmailTo), nil
+	default:
+		return nil, fmt.Errorf("LOW_STOCK_NOTIFIER harus salah satu dari %s", strings.Join(models.NotifierTypes, ", "))
+	}
+}
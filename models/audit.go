@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditEntityProduct     = "product"
+	AuditEntityCategory    = "category"
+	AuditEntityTransaction = "transaction"
+
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+	AuditActionVoid   = "void"
+	AuditActionRefund = "refund"
+)
+
+var AuditEntities = []string{AuditEntityProduct, AuditEntityCategory, AuditEntityTransaction}
+
+// AuditLog mencatat siapa mengubah apa. Before kosong untuk create dan
+// After kosong untuk delete.
+type AuditLog struct {
+	ID            string          `json:"id"`
+	ActorID       *string         `json:"actor_id"`
+	ActorUsername *string         `json:"actor_username"`
+	Entity        string          `json:"entity"`
+	EntityID      string          `json:"entity_id"`
+	Action        string          `json:"action"`
+	Before        json.RawMessage `json:"before"`
+	After         json.RawMessage `json:"after"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// AuditFilter menampung parameter query untuk GET /audit. CreatedFrom
+// (inklusif) dan CreatedTo (eksklusif) diisi service dari StartDate dan
+// EndDate di zona waktu toko.
+type AuditFilter struct {
+	Entity      string
+	EntityID    string
+	ActorID     string
+	StartDate   string
+	EndDate     string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Page        int
+	Limit       int
+}
+
+type AuditListResponse struct {
+	Data  []AuditLog `json:"data"`
+	Page  int        `json:"page"`
+	Limit int        `json:"limit"`
+	Total int        `json:"total"`
+}
@@ -3,18 +3,21 @@ package models
 type Category struct {
 	Name        string  `json:"name"`
 	Description *string `json:"description"`
+	TaxExempt   bool    `json:"tax_exempt"`
 }
 
 type CategoryRequest struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	TaxExempt   bool   `json:"tax_exempt"`
 }
 
 type CategoryResponse struct {
 	ID           string    `json:"id"`
 	Name         string    `json:"name"`
 	Description  *string   `json:"description"`
+	TaxExempt    bool      `json:"tax_exempt"`
 	ProductCount int       `json:"product_count"`
-	Products     []Product `json:"products"`
+	Products     []Product `json:"products,omitempty"`
 }
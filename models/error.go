@@ -0,0 +1,12 @@
+package models
+
+// ErrorResponse adalah bentuk JSON yang sama untuk semua response error
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
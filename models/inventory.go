@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+const (
+	NotifierLog     = "log"
+	NotifierWebhook = "webhook"
+	NotifierEmail   = "email"
+)
+
+var NotifierTypes = []string{
+	NotifierLog,
+	NotifierWebhook,
+	NotifierEmail,
+}
+
+// LowStockProduct adalah produk yang stoknya sudah mencapai atau di bawah
+// reorder point
+type LowStockProduct struct {
+	ProductID    string  `json:"product_id"`
+	ProductName  string  `json:"product_name"`
+	CategoryID   *string `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	Stock        int     `json:"stock"`
+	ReorderPoint int     `json:"reorder_point"`
+	ReorderQty   int     `json:"reorder_qty"`
+}
+
+// LowStockFilter menampung parameter query untuk GET /inventory/low-stock
+type LowStockFilter struct {
+	CategoryID string
+}
+
+type LowStockListResponse struct {
+	Data  []LowStockProduct `json:"data"`
+	Total int               `json:"total"`
+}
+
+// LowStockEvent dikirim ke notifier setelah checkout membuat stok produk
+// turun sampai reorder point
+type LowStockEvent struct {
+	TransactionID string            `json:"transaction_id"`
+	Products      []LowStockProduct `json:"products"`
+	CreatedAt     time.Time         `json:"created_at"`
+}
+
+// NotifierConfig mengatur kanal notifikasi stok menipis. Type berisi salah
+// satu NotifierTypes; WebhookURL dipakai untuk webhook, SMTP* dan EmailTo
+// untuk email.
+type NotifierConfig struct {
+	Type       string
+	WebhookURL string
+	SMTPHost   string
+	SMTPPort   int
+	SMTPFrom   string
+	EmailTo    []string
+}
@@ -0,0 +1,30 @@
+package models
+
+const (
+	PaymentMethodCash      = "cash"
+	PaymentMethodQRIS      = "qris"
+	PaymentMethodDebitCard = "debit_card"
+	PaymentMethodEWallet   = "e_wallet"
+)
+
+// PaymentMethods berisi semua metode pembayaran yang diterima
+var PaymentMethods = []string{PaymentMethodCash, PaymentMethodQRIS, PaymentMethodDebitCard, PaymentMethodEWallet}
+
+type PaymentInput struct {
+	Method string `json:"method"`
+	Amount int    `json:"amount"`
+}
+
+type Payment struct {
+	ID            string `json:"id"`
+	TransactionID string `json:"transaction_id"`
+	Method        string `json:"method"`
+	Tendered      int    `json:"tendered"`
+	Amount        int    `json:"amount"`
+}
+
+type PaymentMethodSummary struct {
+	Method            string `json:"method"`
+	TotalAmount       int    `json:"total_amount"`
+	TotalTransactions int    `json:"total_transactions"`
+}
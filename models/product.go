@@ -1,12 +1,53 @@
 package models
 
+import "time"
+
+// Product adalah barang yang dijual. CostPrice adalah harga pokok (HPP) per
+// unit yang diperbarui otomatis saat penerimaan barang. ReorderPoint adalah
+// batas stok menipis (0 berarti tidak dipantau) dan ReorderQty jumlah yang
+// disarankan untuk dipesan ulang. Barcodes berisi kode EAN-13 (UPC-A
+// disimpan dengan awalan 0). Field pointer dan Barcodes yang kosong saat
+// update mempertahankan nilai lama; SKU "" dan Barcodes [] menghapusnya.
+// Stock selalu terisi pada response; saat update, Stock nil berarti stok
+// tidak diubah.
 type Product struct {
-	ID           string  `json:"id"`
-	Name         string  `json:"name"`
-	Description  *string `json:"description"`
-	Price        int     `json:"price"`
-	Stock        int     `json:"stock"`
-	CategoryID   string  `json:"category_id"`
-	CategoryName string  `json:"category_name"`
-	PictureURL   *string `json:"picture_url,omitempty"`
+	ID           string    `json:"id"`
+	SKU          *string   `json:"sku"`
+	Barcodes     []string  `json:"barcodes"`
+	Name         string    `json:"name"`
+	Description  *string   `json:"description"`
+	Price        int       `json:"price"`
+	CostPrice    *int      `json:"cost_price,omitempty"`
+	Stock        *int      `json:"stock"`
+	ReorderPoint *int      `json:"reorder_point"`
+	ReorderQty   *int      `json:"reorder_qty"`
+	CategoryID   string    `json:"category_id"`
+	CategoryName string    `json:"category_name"`
+	PictureURL   *string   `json:"picture_url,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	// StockReason wajib diisi saat update jika Stock berubah, perubahannya
+	// dicatat sebagai adjustment di ledger stok
+	StockReason string `json:"stock_reason,omitempty"`
+}
+
+// ProductFilter menampung parameter query untuk daftar produk.
+// Sort berisi nama kolom (name, price, stock, created_at), Desc untuk urutan menurun.
+type ProductFilter struct {
+	Name       string
+	CategoryID string
+	MinPrice   *int
+	MaxPrice   *int
+	LowStock   *int
+	Sort       string
+	Desc       bool
+	Limit      int
+	Cursor     string
+}
+
+type ProductListResponse struct {
+	Data       []Product `json:"data"`
+	Total      int       `json:"total"`
+	Limit      int       `json:"limit"`
+	NextCursor *string   `json:"next_cursor"`
 }
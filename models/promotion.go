@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+const (
+	PromotionTypePercentage = "percentage"
+	PromotionTypeFixed      = "fixed"
+	PromotionTypeBuyXGetY   = "buy_x_get_y"
+
+	PromotionScopeProduct  = "product"
+	PromotionScopeCategory = "category"
+	PromotionScopeCart     = "cart"
+)
+
+// Promotion adalah aturan diskon yang dievaluasi saat checkout.
+//
+//   - percentage: Value persen dari harga
+//   - fixed: potongan Value rupiah per unit (scope product/category) atau per transaksi (scope cart)
+//   - buy_x_get_y: setiap membeli BuyQty unit, GetQty unit berikutnya gratis
+//
+// Promosi dengan scope cart hanya berlaku jika belanja (setelah diskon per baris) mencapai MinSpend.
+type Promotion struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	Scope      string     `json:"scope"`
+	ProductID  *string    `json:"product_id,omitempty"`
+	CategoryID *string    `json:"category_id,omitempty"`
+	Value      int        `json:"value"`
+	BuyQty     int        `json:"buy_qty"`
+	GetQty     int        `json:"get_qty"`
+	MinSpend   int        `json:"min_spend"`
+	StartsAt   *time.Time `json:"starts_at"`
+	EndsAt     *time.Time `json:"ends_at"`
+	Active     bool       `json:"active"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
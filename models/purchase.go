@@ -0,0 +1,116 @@
+package models
+
+import "time"
+
+const (
+	PurchaseOrderStatusDraft             = "draft"
+	PurchaseOrderStatusOrdered           = "ordered"
+	PurchaseOrderStatusPartiallyReceived = "partially_received"
+	PurchaseOrderStatusReceived          = "received"
+	PurchaseOrderStatusCancelled         = "cancelled"
+)
+
+var PurchaseOrderStatuses = []string{
+	PurchaseOrderStatusDraft,
+	PurchaseOrderStatusOrdered,
+	PurchaseOrderStatusPartiallyReceived,
+	PurchaseOrderStatusReceived,
+	PurchaseOrderStatusCancelled,
+}
+
+// CostMethod menentukan cara HPP produk diperbarui saat penerimaan barang:
+// average memakai rata-rata tertimbang dengan stok yang ada, last memakai
+// harga beli terakhir.
+const (
+	CostMethodAverage = "average"
+	CostMethodLast    = "last"
+)
+
+var CostMethods = []string{
+	CostMethodAverage,
+	CostMethodLast,
+}
+
+type Supplier struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	ContactName *string   `json:"contact_name"`
+	Phone       *string   `json:"phone"`
+	Email       *string   `json:"email"`
+	Address     *string   `json:"address"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// PurchaseOrder adalah pesanan barang ke supplier. CostPrice pada item adalah
+// harga beli per unit.
+type PurchaseOrder struct {
+	ID           string              `json:"id"`
+	SupplierID   string              `json:"supplier_id"`
+	SupplierName string              `json:"supplier_name"`
+	Status       string              `json:"status"`
+	Note         *string             `json:"note"`
+	TotalAmount  int                 `json:"total_amount"`
+	CreatedBy    *string             `json:"created_by"`
+	CreatedAt    time.Time           `json:"created_at"`
+	OrderedAt    *time.Time          `json:"ordered_at"`
+	ClosedAt     *time.Time          `json:"closed_at"`
+	Items        []PurchaseOrderItem `json:"items"`
+	Receipts     []GoodsReceipt      `json:"receipts,omitempty"`
+}
+
+type PurchaseOrderItem struct {
+	ID               string `json:"id"`
+	ProductID        string `json:"product_id"`
+	ProductName      string `json:"product_name"`
+	Quantity         int    `json:"quantity"`
+	ReceivedQuantity int    `json:"received_quantity"`
+	CostPrice        int    `json:"cost_price"`
+	Subtotal         int    `json:"subtotal"`
+}
+
+type PurchaseOrderItemInput struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	CostPrice int    `json:"cost_price"`
+}
+
+type PurchaseOrderRequest struct {
+	SupplierID string                   `json:"supplier_id"`
+	Note       *string                  `json:"note"`
+	Items      []PurchaseOrderItemInput `json:"items"`
+}
+
+// PurchaseOrderFilter menampung parameter query untuk GET /purchase-orders
+type PurchaseOrderFilter struct {
+	Status     string
+	SupplierID string
+}
+
+type GoodsReceipt struct {
+	ID              string             `json:"id"`
+	PurchaseOrderID string             `json:"purchase_order_id"`
+	Note            *string            `json:"note"`
+	ReceivedBy      *string            `json:"received_by"`
+	CreatedAt       time.Time          `json:"created_at"`
+	Items           []GoodsReceiptItem `json:"items"`
+}
+
+type GoodsReceiptItem struct {
+	ID        string `json:"id"`
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	CostPrice int    `json:"cost_price"`
+}
+
+type GoodsReceiptItemInput struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// GoodsReceiptRequest mencatat barang yang datang. Boleh sebagian dari
+// quantity yang dipesan; sisanya bisa diterima pada penerimaan berikutnya.
+type GoodsReceiptRequest struct {
+	Note  *string                 `json:"note"`
+	Items []GoodsReceiptItemInput `json:"items"`
+}
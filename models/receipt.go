@@ -0,0 +1,32 @@
+package models
+
+const (
+	ReceiptFormatText   = "text"
+	ReceiptFormatESCPOS = "escpos"
+	ReceiptFormatPDF    = "pdf"
+
+	// lebar kertas printer thermal dalam mm
+	PaperWidth58 = 58
+	PaperWidth80 = 80
+)
+
+var ReceiptFormats = []string{ReceiptFormatText, ReceiptFormatESCPOS, ReceiptFormatPDF}
+
+// ReceiptConfig berisi identitas toko yang dicetak di struk. Footer boleh
+// berisi beberapa baris yang dipisah "\n". PaperWidth adalah lebar kertas
+// default jika request tidak menyebutkan width.
+type ReceiptConfig struct {
+	StoreName    string
+	StoreAddress string
+	NPWP         string
+	Footer       string
+	PaperWidth   int
+}
+
+// Receipt adalah data yang dibutuhkan untuk mencetak struk satu transaksi
+type Receipt struct {
+	Config          ReceiptConfig
+	Transaction     Transaction
+	CashierUsername string
+	PaperWidth      int
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+const (
+	TransactionStatusCompleted = "completed"
+	TransactionStatusVoided    = "voided"
+
+	RefundTypeVoid   = "void"
+	RefundTypeRefund = "refund"
+)
+
+type Refund struct {
+	ID            string         `json:"id"`
+	TransactionID string         `json:"transaction_id"`
+	Type          string         `json:"type"`
+	Reason        string         `json:"reason"`
+	TotalAmount   int            `json:"total_amount"`
+	CreatedAt     time.Time      `json:"created_at"`
+	Details       []RefundDetail `json:"details"`
+}
+
+type RefundDetail struct {
+	ID                  string `json:"id"`
+	RefundID            string `json:"refund_id"`
+	TransactionDetailID string `json:"transaction_detail_id"`
+	ProductID           string `json:"product_id"`
+	Quantity            int    `json:"quantity"`
+	Amount              int    `json:"amount"`
+}
+
+type VoidRequest struct {
+	Reason string `json:"reason"`
+}
+
+type RefundItem struct {
+	TransactionDetailID string `json:"transaction_detail_id"`
+	Quantity            int    `json:"quantity"`
+}
+
+type RefundRequest struct {
+	Reason string       `json:"reason"`
+	Items  []RefundItem `json:"items"`
+}
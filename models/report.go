@@ -1,12 +1,98 @@
 package models
 
+import "time"
+
 type Report struct {
-	TotalRevenue        int                `json:"total_revenue"`
-	TotalTransactions   int                `json:"total_transactions"`
-	BestSellingProducts BestSellingProduct `json:"best_selling_products"`
+	TotalRevenue          int                    `json:"total_revenue"`
+	TotalRefunds          int                    `json:"total_refunds"`
+	TotalTransactions     int                    `json:"total_transactions"`
+	BestSellingProducts   []BestSellingProduct   `json:"best_selling_products"`
+	BestSellingCategories []BestSellingCategory  `json:"best_selling_categories"`
+	PaymentMethods        []PaymentMethodSummary `json:"payment_methods"`
+	Margin                MarginSummary          `json:"margin"`
+	ProductMargins        []ProductMargin        `json:"product_margins"`
+	CategoryMargins       []CategoryMargin       `json:"category_margins"`
 }
 
+// BestSellingProduct adalah satu baris peringkat produk terlaris. Share
+// dihitung terhadap total quantity dan revenue seluruh produk pada periode.
 type BestSellingProduct struct {
-	Name    string `json:"name"`
-	QtySold int    `json:"qty_sold"`
+	Rank                int     `json:"rank"`
+	ProductID           string  `json:"product_id"`
+	Name                string  `json:"name"`
+	CategoryID          *string `json:"category_id"`
+	QtySold             int     `json:"qty_sold"`
+	Revenue             int     `json:"revenue"`
+	QtySharePercent     float64 `json:"qty_share_percent"`
+	RevenueSharePercent float64 `json:"revenue_share_percent"`
+}
+
+type BestSellingCategory struct {
+	Rank                int     `json:"rank"`
+	CategoryID          *string `json:"category_id"`
+	Name                string  `json:"name"`
+	QtySold             int     `json:"qty_sold"`
+	Revenue             int     `json:"revenue"`
+	QtySharePercent     float64 `json:"qty_share_percent"`
+	RevenueSharePercent float64 `json:"revenue_share_percent"`
+}
+
+// MarginSummary merangkum laba kotor. NetSales adalah penjualan setelah diskon
+// tanpa PPN, Cogs adalah HPP barang terjual berdasarkan snapshot HPP di baris
+// transaksi. Refund mengurangi keduanya pada hari refund dilakukan.
+type MarginSummary struct {
+	NetSales           int     `json:"net_sales"`
+	Cogs               int     `json:"cogs"`
+	GrossProfit        int     `json:"gross_profit"`
+	GrossMarginPercent float64 `json:"gross_margin_percent"`
+}
+
+type ProductMargin struct {
+	ProductID   string  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	CategoryID  *string `json:"category_id"`
+	QtySold     int     `json:"qty_sold"`
+	MarginSummary
+}
+
+type CategoryMargin struct {
+	CategoryID   *string `json:"category_id"`
+	CategoryName string  `json:"category_name"`
+	QtySold      int     `json:"qty_sold"`
+	MarginSummary
+}
+
+const (
+	GranularityHour  = "hour"
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+var Granularities = []string{
+	GranularityHour,
+	GranularityDay,
+	GranularityWeek,
+	GranularityMonth,
+}
+
+// TimeSeriesBucket merangkum penjualan dalam satu bucket waktu. Start adalah
+// awal bucket di zona waktu toko; minggu dimulai hari Senin.
+// AverageBasketValue adalah revenue per transaksi dan AverageBasketSize
+// jumlah item per transaksi.
+type TimeSeriesBucket struct {
+	Start              time.Time `json:"start"`
+	Revenue            int       `json:"revenue"`
+	TransactionCount   int       `json:"transaction_count"`
+	ItemsSold          int       `json:"items_sold"`
+	AverageBasketValue int       `json:"average_basket_value"`
+	AverageBasketSize  float64   `json:"average_basket_size"`
+}
+
+type TimeSeriesReport struct {
+	Granularity string             `json:"granularity"`
+	StartDate   string             `json:"start_date"`
+	EndDate     string             `json:"end_date"`
+	Timezone    string             `json:"timezone"`
+	Buckets     []TimeSeriesBucket `json:"buckets"`
 }
@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+const (
+	ShiftStatusOpen   = "open"
+	ShiftStatusClosed = "closed"
+
+	CashMovementIn  = "cash_in"
+	CashMovementOut = "cash_out"
+)
+
+// Shift adalah satu sesi kerja kasir. CountedCash dan Variance baru terisi
+// setelah shift ditutup. ExpectedCash pada shift closed adalah nilai saat
+// shift ditutup; pada shift open hanya terisi di Z-report, dihitung dari
+// data terkini.
+type Shift struct {
+	ID              string     `json:"id"`
+	CashierID       string     `json:"cashier_id"`
+	CashierUsername string     `json:"cashier_username"`
+	Status          string     `json:"status"`
+	OpeningFloat    int        `json:"opening_float"`
+	Note            *string    `json:"note"`
+	OpenedAt        time.Time  `json:"opened_at"`
+	ClosedBy        *string    `json:"closed_by"`
+	ClosedAt        *time.Time `json:"closed_at"`
+	ExpectedCash    *int       `json:"expected_cash"`
+	CountedCash     *int       `json:"counted_cash"`
+	Variance        *int       `json:"variance"`
+}
+
+type OpenShiftRequest struct {
+	OpeningFloat int     `json:"opening_float"`
+	Note         *string `json:"note"`
+}
+
+type CashMovementRequest struct {
+	Type   string `json:"type"`
+	Amount int    `json:"amount"`
+	Reason string `json:"reason"`
+}
+
+type CloseShiftRequest struct {
+	CountedCash *int    `json:"counted_cash"`
+	Note        *string `json:"note"`
+}
+
+type CashMovement struct {
+	ID        string    `json:"id"`
+	ShiftID   string    `json:"shift_id"`
+	Type      string    `json:"type"`
+	Amount    int       `json:"amount"`
+	Reason    string    `json:"reason"`
+	CreatedBy *string   `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ShiftFilter menampung parameter query untuk GET /shifts
+type ShiftFilter struct {
+	Status    string
+	CashierID string
+}
+
+// ShiftReport adalah Z-report sebuah shift. PaymentMethods berisi penjualan
+// kotor per metode termasuk transaksi yang kemudian di-void; void dan refund
+// dicatat terpisah di Refunds. CashRefunds adalah bagian refund yang
+// dikembalikan tunai, yaitu refund sebuah transaksi hingga sebesar
+// pembayaran tunainya. ExpectedCash = OpeningFloat + CashSales + CashIn -
+// CashOut - CashRefunds.
+type ShiftReport struct {
+	Shift
+	TransactionCount int                    `json:"transaction_count"`
+	VoidCount        int                    `json:"void_count"`
+	GrossSales       int                    `json:"gross_sales"`
+	PaymentMethods   []PaymentMethodSummary `json:"payment_methods"`
+	RefundCount      int                    `json:"refund_count"`
+	Refunds          int                    `json:"refunds"`
+	NetSales         int                    `json:"net_sales"`
+	CashSales        int                    `json:"cash_sales"`
+	CashRefunds      int                    `json:"cash_refunds"`
+	CashIn           int                    `json:"cash_in"`
+	CashOut          int                    `json:"cash_out"`
+	CashMovements    []CashMovement         `json:"cash_movements"`
+}
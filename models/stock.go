@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+const (
+	StockMovementSale            = "sale"
+	StockMovementRefund          = "refund"
+	StockMovementPurchaseReceipt = "purchase_receipt"
+	StockMovementAdjustment      = "adjustment"
+	StockMovementWaste           = "waste"
+	StockMovementStockTake       = "stock_take"
+)
+
+var StockMovementTypes = []string{
+	StockMovementSale,
+	StockMovementRefund,
+	StockMovementPurchaseReceipt,
+	StockMovementAdjustment,
+	StockMovementWaste,
+	StockMovementStockTake,
+}
+
+// StockMovement adalah satu baris ledger stok. Quantity positif menambah stok
+// dan negatif mengurangi; StockAfter adalah saldo setelah pergerakan ini.
+type StockMovement struct {
+	ID          string    `json:"id"`
+	ProductID   string    `json:"product_id"`
+	Type        string    `json:"type"`
+	Quantity    int       `json:"quantity"`
+	StockAfter  int       `json:"stock_after"`
+	ReferenceID *string   `json:"reference_id"`
+	Reason      *string   `json:"reason"`
+	ActorID     *string   `json:"actor_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// StockAdjustmentRequest dipakai untuk koreksi stok manual. Untuk type
+// adjustment, Quantity boleh positif atau negatif; untuk waste, Quantity
+// adalah jumlah barang yang dibuang dan selalu mengurangi stok.
+type StockAdjustmentRequest struct {
+	Type     string `json:"type"`
+	Quantity int    `json:"quantity"`
+	Reason   string `json:"reason"`
+}
+
+// StockMovementFilter menampung parameter query untuk GET /products/{id}/stock-movements
+type StockMovementFilter struct {
+	ProductID string
+	Type      string
+	Page      int
+	Limit     int
+}
+
+type StockMovementListResponse struct {
+	Data  []StockMovement `json:"data"`
+	Page  int             `json:"page"`
+	Limit int             `json:"limit"`
+	Total int             `json:"total"`
+}
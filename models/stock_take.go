@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+const (
+	StockTakeStatusOpen      = "open"
+	StockTakeStatusFinalized = "finalized"
+	StockTakeStatusCancelled = "cancelled"
+
+	// StockTakeCountModeSet mengganti hasil hitung sebelumnya, sedangkan
+	// StockTakeCountModeAdd menambahkannya (produk yang ada di beberapa rak)
+	StockTakeCountModeSet = "set"
+	StockTakeCountModeAdd = "add"
+)
+
+type StockTake struct {
+	ID        string     `json:"id"`
+	Status    string     `json:"status"`
+	Note      *string    `json:"note"`
+	OpenedBy  *string    `json:"opened_by"`
+	OpenedAt  time.Time  `json:"opened_at"`
+	ClosedBy  *string    `json:"closed_by"`
+	ClosedAt  *time.Time `json:"closed_at"`
+	ItemCount int        `json:"item_count"`
+}
+
+type StockTakeRequest struct {
+	Note *string `json:"note"`
+}
+
+type StockTakeCountItem struct {
+	ProductID       string `json:"product_id"`
+	CountedQuantity int    `json:"counted_quantity"`
+}
+
+// StockTakeCountRequest adalah satu batch hasil hitung dari satu perangkat
+type StockTakeCountRequest struct {
+	Mode  string               `json:"mode"`
+	Items []StockTakeCountItem `json:"items"`
+}
+
+// StockTakeLine adalah selisih satu produk. VarianceValue dinilai dengan
+// harga jual saat ini.
+type StockTakeLine struct {
+	ProductID       string    `json:"product_id"`
+	ProductName     string    `json:"product_name"`
+	SystemStock     int       `json:"system_stock"`
+	CountedQuantity int       `json:"counted_quantity"`
+	Variance        int       `json:"variance"`
+	Price           int       `json:"price"`
+	VarianceValue   int       `json:"variance_value"`
+	CountedBy       *string   `json:"counted_by"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type StockTakeReport struct {
+	StockTake
+	SurplusValue  int             `json:"surplus_value"`
+	ShortageValue int             `json:"shortage_value"`
+	VarianceValue int             `json:"variance_value"`
+	Lines         []StockTakeLine `json:"lines"`
+}
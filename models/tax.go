@@ -0,0 +1,26 @@
+package models
+
+// TaxConfig mengatur perhitungan PPN dan service charge saat checkout.
+// Rate dan ServiceChargeRate dalam persen, contoh 11 untuk PPN 11%.
+// Jika Inclusive bernilai true, harga produk sudah termasuk PPN.
+type TaxConfig struct {
+	Rate              float64
+	Inclusive         bool
+	ServiceChargeRate float64
+}
+
+type TaxReportRow struct {
+	Date                string `json:"date"`
+	TaxableAmount       int    `json:"taxable_amount"`
+	ServiceChargeAmount int    `json:"service_charge_amount"`
+	TaxAmount           int    `json:"tax_amount"`
+}
+
+type TaxReport struct {
+	StartDate           string         `json:"start_date"`
+	EndDate             string         `json:"end_date"`
+	TaxableAmount       int            `json:"taxable_amount"`
+	ServiceChargeAmount int            `json:"service_charge_amount"`
+	TaxAmount           int            `json:"tax_amount"`
+	Days                []TaxReportRow `json:"days"`
+}
@@ -3,26 +3,114 @@ package models
 import "time"
 
 type Transaction struct {
-	ID          string              `json:"id"`
-	TotalAmount int                 `json:"total_amount"`
-	CreatedAt   time.Time           `json:"created_at"`
-	Details     []TransactionDetail `json:"details"`
+	ID                  string              `json:"id"`
+	GrossAmount         int                 `json:"gross_amount"`
+	DiscountAmount      int                 `json:"discount_amount"`
+	ServiceChargeAmount int                 `json:"service_charge_amount"`
+	TaxAmount           int                 `json:"tax_amount"`
+	TaxRate             float64             `json:"tax_rate"`
+	TaxInclusive        bool                `json:"tax_inclusive"`
+	TotalAmount         int                 `json:"total_amount"`
+	PromotionID         *string             `json:"promotion_id,omitempty"`
+	CashierID           *string             `json:"cashier_id"`
+	ShiftID             *string             `json:"shift_id"`
+	PaidAmount          int                 `json:"paid_amount"`
+	ChangeAmount        int                 `json:"change_amount"`
+	Status              string              `json:"status"`
+	VoidedAt            *time.Time          `json:"voided_at,omitempty"`
+	VoidReason          *string             `json:"void_reason,omitempty"`
+	CreatedAt           time.Time           `json:"created_at"`
+	Details             []TransactionDetail `json:"details"`
+	Payments            []Payment           `json:"payments"`
+	Refunds             []Refund            `json:"refunds,omitempty"`
 }
 
+// TransactionDetail menyimpan satu baris transaksi. GrossAmount adalah harga x
+// quantity, DiscountAmount mencakup diskon baris dan bagian diskon keranjang,
+// dan Subtotal adalah nilai yang dibayar termasuk service charge dan PPN.
+// CostPrice adalah HPP per unit saat transaksi terjadi.
 type TransactionDetail struct {
-	ID            string `json:"id"`
-	TransactionID string `json:"transaction_id"`
-	ProductID     string `json:"product_id"`
-	ProductName   string `json:"product_name"`
-	Quantity      int    `json:"quantity"`
-	Subtotal      int    `json:"subtotal"`
+	ID                  string  `json:"id"`
+	TransactionID       string  `json:"transaction_id"`
+	ProductID           string  `json:"product_id"`
+	ProductName         string  `json:"product_name"`
+	Quantity            int     `json:"quantity"`
+	GrossAmount         int     `json:"gross_amount"`
+	DiscountAmount      int     `json:"discount_amount"`
+	PromotionID         *string `json:"promotion_id,omitempty"`
+	ServiceChargeAmount int     `json:"service_charge_amount"`
+	TaxAmount           int     `json:"tax_amount"`
+	Subtotal            int     `json:"subtotal"`
+	CostPrice           int     `json:"cost_price"`
 }
 
+// CheckoutItem menunjuk produk lewat product_id atau barcode hasil scan;
+// tepat satu dari keduanya harus diisi
 type CheckoutItem struct {
-	ProductID string `json:"product_id"`
+	ProductID string `json:"product_id,omitempty"`
+	Barcode   string `json:"barcode,omitempty"`
 	Quantity  int    `json:"quantity"`
 }
 
 type CheckoutRequest struct {
-	Items []CheckoutItem `json:"items"`
+	Items    []CheckoutItem `json:"items"`
+	Payments []PaymentInput `json:"payments"`
+
+	// IdempotencyKey diambil dari header Idempotency-Key, bukan dari body
+	IdempotencyKey string `json:"-"`
+	// Cashier diisi dari user yang login
+	Cashier *AuthUser `json:"-"`
+}
+
+type IdempotencyKey struct {
+	Key           string
+	RequestHash   string
+	TransactionID string
+}
+
+// TransactionFilter menampung parameter query untuk GET /transactions.
+// CreatedFrom (inklusif) dan CreatedTo (eksklusif) diisi service dari
+// StartDate dan EndDate di zona waktu toko.
+type TransactionFilter struct {
+	StartDate   string
+	EndDate     string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	ProductID   string
+	ShiftID     string
+	MinAmount   *int
+	MaxAmount   *int
+	Page        int
+	Limit       int
+}
+
+// TransactionExportRow adalah satu baris ekspor daftar transaksi. Details dan
+// Payments pada Transaction tidak diisi; PaymentMethods berisi metode
+// pembayaran yang dipakai, dipisah koma.
+type TransactionExportRow struct {
+	Transaction
+	CashierUsername string
+	ItemCount       int
+	PaymentMethods  string
+}
+
+type TransactionListResponse struct {
+	Data  []Transaction `json:"data"`
+	Page  int           `json:"page"`
+	Limit int           `json:"limit"`
+	Total int           `json:"total"`
+}
+
+// StockShortage menjelaskan satu produk yang stoknya tidak cukup saat checkout
+type StockShortage struct {
+	ProductID   string `json:"product_id"`
+	ProductName string `json:"product_name"`
+	Requested   int    `json:"requested"`
+	Available   int    `json:"available"`
+}
+
+// FieldError menunjuk field request yang tidak valid, contoh "items[1].quantity"
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+const (
+	RoleOwner   = "owner"
+	RoleManager = "manager"
+	RoleCashier = "cashier"
+)
+
+var Roles = []string{RoleOwner, RoleManager, RoleCashier}
+
+type User struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	Name         string    `json:"name"`
+	Role         string    `json:"role"`
+	Active       bool      `json:"active"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// UserRequest dipakai untuk membuat dan mengubah user. Password boleh kosong
+// saat update jika password tidak diganti.
+type UserRequest struct {
+	Username string `json:"username"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+	Active   *bool  `json:"active"`
+}
+
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type LoginResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      User      `json:"user"`
+}
+
+// AuthUser adalah identitas user yang dibaca dari token pada setiap request
+type AuthUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// AuthConfig mengatur penandatanganan token login
+type AuthConfig struct {
+	Secret   string
+	TokenTTL time.Duration
+}
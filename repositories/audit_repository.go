@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"labkoding.my.id/kasir-api/models"
+)
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+// insertAuditLog menulis entri audit di dalam transaksi database yang sama
+// dengan perubahannya, sehingga audit ikut batal jika perubahan gagal.
+// actor nil berarti perubahan dilakukan oleh sistem.
+func insertAuditLog(tx *sql.Tx, actor *models.AuthUser, entity, entityID, action string, before, after interface{}) error {
+	beforeData, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	afterData, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	var actorID, actorUsername *string
+	if actor != nil {
+		actorID, actorUsername = &actor.ID, &actor.Username
+	}
+
+	_, err = tx.Exec("INSERT INTO audit_logs (actor_id, actor_username, entity, entity_id, action, before_data, after_data) VALUES ($1, $2, $3, $4, $5, $6::jsonb, $7::jsonb)",
+		actorID, actorUsername, entity, entityID, action, beforeData, afterData)
+	return err
+}
+
+func auditJSON(v interface{}) (*string, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	s := string(b)
+	return &s, nil
+}
+
+func (r *AuditRepository) GetAllAuditLogs(filter models.AuditFilter) ([]models.AuditLog, int, error) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if filter.Entity != "" {
+		args = append(args, filter.Entity)
+		conditions = append(conditions, fmt.Sprintf("entity = $%d", len(args)))
+	}
+	if filter.EntityID != "" {
+		args = append(args, filter.EntityID)
+		conditions = append(conditions, fmt.Sprintf("entity_id = $%d", len(args)))
+	}
+	if filter.ActorID != "" {
+		args = append(args, filter.ActorID)
+		conditions = append(conditions, fmt.Sprintf("actor_id = $%d", len(args)))
+	}
+	if filter.CreatedFrom != nil {
+		args = append(args, *filter.CreatedFrom)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d::timestamptz", len(args)))
+	}
+	if filter.CreatedTo != nil {
+		args = append(args, *filter.CreatedTo)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d::timestamptz", len(args)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM audit_logs"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT id, actor_id, actor_username, entity, entity_id, action, before_data, after_data, created_at::timestamptz FROM audit_logs" + where
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	logs := make([]models.AuditLog, 0)
+	for rows.Next() {
+		var log models.AuditLog
+		var before, after []byte
+		if err := rows.Scan(&log.ID, &log.ActorID, &log.ActorUsername, &log.Entity, &log.EntityID, &log.Action, &before, &after, &log.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		log.Before, log.After = before, after
+		logs = append(logs, log)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
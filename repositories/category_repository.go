@@ -3,7 +3,6 @@ package repositories
 import (
 	"database/sql"
 	"encoding/json"
-	"errors"
 
 	"labkoding.my.id/kasir-api/models"
 )
@@ -18,38 +17,54 @@ func NewCategoryRepository(db *sql.DB) *CategoryRepository {
 	}
 }
 
-func (r *CategoryRepository) GetAllCategories(name string) ([]models.CategoryResponse, error) {
-	var categories []models.CategoryResponse
+// GetAllCategories mengembalikan daftar kategori beserta jumlah produknya.
+// Produk hanya disertakan jika includeProducts bernilai true karena ukurannya
+// bertambah seiring jumlah produk; gunakan GET /categories/{id}/products untuk daftar berhalaman.
+func (r *CategoryRepository) GetAllCategories(name string, includeProducts bool) ([]models.CategoryResponse, error) {
+	categories := make([]models.CategoryResponse, 0)
 
 	args := []interface{}{}
-	// SQL efisien: 1 query dengan json_agg untuk mengumpulkan products per kategori
-	// NOTE: Pastikan database yang digunakan adalah PostgreSQL
-	selectPart := `
-			SELECT
-				c.id,
-				c.name,
-				c.description,
-				COUNT(p.id) AS product_count,
-				COALESCE(
+	productsPart := "NULL"
+	if includeProducts {
+		// SQL efisien: 1 query dengan json_agg untuk mengumpulkan products per kategori
+		// NOTE: Pastikan database yang digunakan adalah PostgreSQL
+		productsPart = `COALESCE(
 					json_agg(
 						json_build_object(
 							'id', p.id,
+							'sku', p.sku,
+							'barcodes', COALESCE((SELECT json_agg(pb.barcode ORDER BY pb.barcode) FROM product_barcodes pb WHERE pb.product_id = p.id), '[]'),
 							'name', p.name,
 							'description', p.description,
 							'price', p.price,
+							'cost_price', p.cost_price,
 							'stock', p.stock,
+							'reorder_point', p.reorder_point,
+							'reorder_qty', p.reorder_qty,
 							'category_id', p.category_id,
-							'category_name', c.name
-						)
+							'category_name', c.name,
+							'picture_url', p.picture_url,
+							'created_at', to_char(p.created_at::timestamptz AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"')
+						) ORDER BY p.name, p.id
 					) FILTER (WHERE p.id IS NOT NULL),
 					'[]'
-				) AS products
+				)`
+	}
+
+	selectPart := `
+			SELECT
+				c.id,
+				c.name,
+				c.description,
+				c.tax_exempt,
+				COUNT(p.id) AS product_count,
+				` + productsPart + ` AS products
 			FROM categories c
 			LEFT JOIN products p ON c.id = p.category_id
 			`
 
 	groupOrderPart := `
-		GROUP BY c.id, c.name, c.description
+		GROUP BY c.id, c.name, c.description, c.tax_exempt
 		ORDER BY c.id;
 		`
 
@@ -72,13 +87,15 @@ func (r *CategoryRepository) GetAllCategories(name string) ([]models.CategoryRes
 		var category models.CategoryResponse
 		var productsJSON []byte
 
-		if err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.ProductCount, &productsJSON); err != nil {
+		if err := rows.Scan(&category.ID, &category.Name, &category.Description, &category.TaxExempt, &category.ProductCount, &productsJSON); err != nil {
 			return nil, err
 		}
 
 		// Unmarshal produk JSON ke []models.Product
-		if err := json.Unmarshal(productsJSON, &category.Products); err != nil {
-			return nil, err
+		if productsJSON != nil {
+			if err := json.Unmarshal(productsJSON, &category.Products); err != nil {
+				return nil, err
+			}
 		}
 
 		categories = append(categories, category)
@@ -94,11 +111,11 @@ func (r *CategoryRepository) GetAllCategories(name string) ([]models.CategoryRes
 func (r *CategoryRepository) GetCategoryByID(id string) (*models.Category, error) {
 	var category models.Category
 
-	row := r.db.QueryRow("SELECT name, description FROM categories WHERE id = $1", id)
+	row := r.db.QueryRow("SELECT name, description, tax_exempt FROM categories WHERE id = $1", id)
 
-	if err := row.Scan(&category.Name, &category.Description); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("category tidak ditemukan")
+	if err := row.Scan(&category.Name, &category.Description, &category.TaxExempt); err != nil {
+		if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+			return nil, ErrCategoryNotFound
 		}
 		return nil, err
 	}
@@ -106,40 +123,87 @@ func (r *CategoryRepository) GetCategoryByID(id string) (*models.Category, error
 	return &category, nil
 }
 
-func (r *CategoryRepository) CreateCategory(category *models.CategoryRequest) error {
-	err := r.db.QueryRow("INSERT INTO categories (name, description) VALUES ($1, $2) returning id", category.Name, category.Description).Scan(&category.ID)
-	return err
+// getCategoryForUpdate mengunci dan membaca kategori sebelum diubah untuk audit
+func getCategoryForUpdate(tx *sql.Tx, id string) (*models.CategoryRequest, error) {
+	category := models.CategoryRequest{ID: id}
+
+	err := tx.QueryRow("SELECT name, COALESCE(description, ''), tax_exempt FROM categories WHERE id = $1 FOR UPDATE", id).Scan(&category.Name, &category.Description, &category.TaxExempt)
+	if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &category, nil
 }
 
-func (r *CategoryRepository) UpdateCategory(category *models.CategoryRequest) error {
-	result, err := r.db.Exec("UPDATE categories SET name = $1, description = $2 WHERE id = $3", category.Name, category.Description, category.ID)
+func (r *CategoryRepository) CreateCategory(category *models.CategoryRequest, actor *models.AuthUser) error {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
+	err = tx.QueryRow("INSERT INTO categories (name, description, tax_exempt) VALUES ($1, $2, $3) returning id", category.Name, category.Description, category.TaxExempt).Scan(&category.ID)
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return errors.New("category tidak ditemukan")
+	if err := insertAuditLog(tx, actor, models.AuditEntityCategory, category.ID, models.AuditActionCreate, nil, category); err != nil {
+		return err
 	}
-	return err
+
+	return tx.Commit()
 }
 
-func (r *CategoryRepository) DeleteCategory(id string) error {
-	result, err := r.db.Exec("DELETE FROM categories WHERE id = $1", id)
+func (r *CategoryRepository) UpdateCategory(category *models.CategoryRequest, actor *models.AuthUser) error {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
-	rows, err := result.RowsAffected()
+	defer tx.Rollback()
+
+	before, err := getCategoryForUpdate(tx, category.ID)
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return errors.New("category tidak ditemukan")
+	_, err = tx.Exec("UPDATE categories SET name = $1, description = $2, tax_exempt = $3 WHERE id = $4", category.Name, category.Description, category.TaxExempt, category.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := insertAuditLog(tx, actor, models.AuditEntityCategory, category.ID, models.AuditActionUpdate, before, category); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *CategoryRepository) DeleteCategory(id string, actor *models.AuthUser) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := getCategoryForUpdate(tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM categories WHERE id = $1", id)
+	if isForeignKeyViolation(err) {
+		return ErrCategoryInUse
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := insertAuditLog(tx, actor, models.AuditEntityCategory, id, models.AuditActionDelete, before, nil); err != nil {
+		return err
 	}
-	return err
+
+	return tx.Commit()
 }
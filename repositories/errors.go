@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+	"labkoding.my.id/kasir-api/apperror"
+)
+
+var (
+	ErrCategoryNotFound = apperror.NotFound("CATEGORY_NOT_FOUND", "category tidak ditemukan")
+	ErrCategoryInUse    = apperror.Conflict("CATEGORY_IN_USE", "category masih memiliki produk")
+	ErrInvalidCategory  = apperror.Unprocessable("INVALID_CATEGORY", "category_id tidak ditemukan")
+
+	ErrProductNotFound = apperror.NotFound("PRODUCT_NOT_FOUND", "produk tidak ditemukan")
+	ErrProductInUse    = apperror.Conflict("PRODUCT_IN_USE", "produk sudah dipakai di transaksi")
+	ErrInvalidCursor   = apperror.BadRequest("INVALID_CURSOR", "cursor tidak valid")
+	ErrSKUExists       = apperror.Conflict("SKU_EXISTS", "sku sudah dipakai produk lain")
+	ErrBarcodeExists   = apperror.Conflict("BARCODE_EXISTS", "barcode sudah dipakai produk lain")
+
+	ErrNegativeStock       = apperror.Conflict("NEGATIVE_STOCK", "stok tidak boleh kurang dari 0")
+	ErrStockReasonRequired = apperror.Unprocessable("STOCK_REASON_REQUIRED", "perubahan stok wajib disertai stock_reason")
+
+	ErrStockTakeNotFound = apperror.NotFound("STOCK_TAKE_NOT_FOUND", "sesi stock take tidak ditemukan")
+	ErrStockTakeClosed   = apperror.Conflict("STOCK_TAKE_CLOSED", "sesi stock take sudah ditutup")
+
+	ErrShiftNotFound    = apperror.NotFound("SHIFT_NOT_FOUND", "shift tidak ditemukan")
+	ErrNoOpenShift      = apperror.NotFound("NO_OPEN_SHIFT", "tidak ada shift yang sedang dibuka")
+	ErrShiftAlreadyOpen = apperror.Conflict("SHIFT_ALREADY_OPEN", "kasir masih memiliki shift yang belum ditutup")
+	ErrShiftClosed      = apperror.Conflict("SHIFT_CLOSED", "shift sudah ditutup")
+
+	ErrPromotionNotFound = apperror.NotFound("PROMOTION_NOT_FOUND", "promosi tidak ditemukan")
+
+	ErrSupplierNotFound          = apperror.NotFound("SUPPLIER_NOT_FOUND", "supplier tidak ditemukan")
+	ErrSupplierInUse             = apperror.Conflict("SUPPLIER_IN_USE", "supplier sudah memiliki purchase order, nonaktifkan supplier sebagai gantinya")
+	ErrInvalidSupplier           = apperror.Unprocessable("INVALID_SUPPLIER", "supplier_id tidak ditemukan atau tidak aktif")
+	ErrInvalidProduct            = apperror.Unprocessable("INVALID_PRODUCT", "product_id tidak ditemukan")
+	ErrPurchaseOrderNotFound     = apperror.NotFound("PURCHASE_ORDER_NOT_FOUND", "purchase order tidak ditemukan")
+	ErrPurchaseOrderStatus       = apperror.Conflict("PURCHASE_ORDER_INVALID_STATUS", "status purchase order tidak mengizinkan aksi ini")
+	ErrPurchaseOrderItemNotFound = apperror.Unprocessable("PURCHASE_ORDER_ITEM_NOT_FOUND", "produk tidak ada di purchase order")
+	ErrReceiptExceedsOrdered     = apperror.Conflict("RECEIPT_EXCEEDS_ORDERED", "jumlah diterima melebihi sisa pesanan")
+
+	ErrUserNotFound   = apperror.NotFound("USER_NOT_FOUND", "user tidak ditemukan")
+	ErrUsernameExists = apperror.Conflict("USERNAME_EXISTS", "username sudah dipakai")
+	ErrUserInUse      = apperror.Conflict("USER_IN_USE", "user sudah memiliki transaksi, nonaktifkan user sebagai gantinya")
+
+	ErrTransactionNotFound       = apperror.NotFound("TRANSACTION_NOT_FOUND", "transaksi tidak ditemukan")
+	ErrTransactionVoided         = apperror.Conflict("TRANSACTION_VOIDED", "transaksi sudah dibatalkan")
+	ErrTransactionFullyRefunded  = apperror.Conflict("TRANSACTION_FULLY_REFUNDED", "transaksi sudah direfund seluruhnya")
+	ErrTransactionDetailNotFound = apperror.Unprocessable("TRANSACTION_DETAIL_NOT_FOUND", "detail transaksi tidak ditemukan")
+	ErrTransactionFrozen         = apperror.Conflict("TRANSACTION_FROZEN", "transaksi tidak bisa diubah karena shift-nya sudah ditutup")
+	ErrRefundExceedsQuantity     = apperror.Conflict("REFUND_EXCEEDS_QUANTITY", "jumlah refund melebihi sisa quantity")
+	ErrInsufficientStock         = apperror.Conflict("INSUFFICIENT_STOCK", "stok tidak mencukupi")
+	ErrInsufficientPayment       = apperror.Unprocessable("INSUFFICIENT_PAYMENT", "pembayaran kurang dari total transaksi")
+	ErrNonCashOverpayment        = apperror.Unprocessable("NON_CASH_OVERPAYMENT", "pembayaran non-tunai melebihi total transaksi")
+	ErrIdempotencyKeyExists      = apperror.Conflict("IDEMPOTENCY_KEY_EXISTS", "idempotency key sudah digunakan")
+	ErrIdempotencyKeyNotFound    = apperror.NotFound("IDEMPOTENCY_KEY_NOT_FOUND", "idempotency key tidak ditemukan")
+)
+
+// isForeignKeyViolation mengecek error Postgres 23503 (foreign_key_violation)
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// isUniqueViolation mengecek error Postgres 23505 (unique_violation)
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// isInvalidTextRepresentation mengecek error Postgres 22P02
+// (invalid_text_representation), misalnya id yang bukan UUID. Untuk id dari
+// path, error ini diperlakukan sama dengan data yang tidak ditemukan.
+func isInvalidTextRepresentation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "22P02"
+}
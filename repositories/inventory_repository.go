@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"labkoding.my.id/kasir-api/models"
+)
+
+const lowStockSelect = "SELECT p.id, p.name, p.category_id, COALESCE(c.name, ''), p.stock, p.reorder_point, p.reorder_qty FROM products p LEFT JOIN categories c ON c.id = p.category_id"
+
+type InventoryRepository struct {
+	db *sql.DB
+}
+
+func NewInventoryRepository(db *sql.DB) *InventoryRepository {
+	return &InventoryRepository{
+		db: db,
+	}
+}
+
+func scanLowStockProducts(rows *sql.Rows) ([]models.LowStockProduct, error) {
+	defer rows.Close()
+
+	products := make([]models.LowStockProduct, 0)
+	for rows.Next() {
+		var product models.LowStockProduct
+		if err := rows.Scan(&product.ProductID, &product.ProductName, &product.CategoryID, &product.CategoryName, &product.Stock, &product.ReorderPoint, &product.ReorderQty); err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// GetLowStockProducts mengembalikan produk yang stoknya sudah mencapai reorder
+// point, diurutkan dari yang paling jauh di bawah batas
+func (r *InventoryRepository) GetLowStockProducts(filter models.LowStockFilter) ([]models.LowStockProduct, error) {
+	query := lowStockSelect + " WHERE p.reorder_point > 0 AND p.stock <= p.reorder_point"
+	args := []interface{}{}
+	if filter.CategoryID != "" {
+		args = append(args, filter.CategoryID)
+		query += " AND p.category_id = $1"
+	}
+	query += " ORDER BY p.stock - p.reorder_point, p.name"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanLowStockProducts(rows)
+}
@@ -2,11 +2,70 @@ package repositories
 
 import (
 	"database/sql"
-	"errors"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
 
+	"github.com/lib/pq"
+	"labkoding.my.id/kasir-api/apperror"
 	"labkoding.my.id/kasir-api/models"
 )
 
+// productSortColumns memetakan nilai parameter sort ke kolom database
+var productSortColumns = map[string]string{
+	"name":       "products.name",
+	"price":      "products.price",
+	"stock":      "products.stock",
+	"created_at": "products.created_at",
+}
+
+const productSelect = "SELECT products.id, products.sku, COALESCE((SELECT array_agg(pb.barcode ORDER BY pb.barcode) FROM product_barcodes pb WHERE pb.product_id = products.id), '{}'), products.name, products.description, products.price, products.cost_price, products.stock, products.reorder_point, products.reorder_qty, products.picture_url, categories.id as category_id, categories.name as category_name, products.created_at::timestamptz FROM products LEFT JOIN categories ON products.category_id = categories.id"
+
+// productCursor menyimpan posisi baris terakhir untuk keyset pagination
+type productCursor struct {
+	Sort  string `json:"s"`
+	Desc  bool   `json:"d"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func encodeProductCursor(filter models.ProductFilter, product models.Product) string {
+	cursor := productCursor{Sort: filter.Sort, Desc: filter.Desc, ID: product.ID}
+	switch filter.Sort {
+	case "name":
+		cursor.Value = product.Name
+	case "price":
+		cursor.Value = fmt.Sprint(product.Price)
+	case "stock":
+		cursor.Value = fmt.Sprint(*product.Stock)
+	case "created_at":
+		cursor.Value = product.CreatedAt.Format("2006-01-02 15:04:05.999999")
+	}
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeProductCursor memastikan cursor dibuat untuk sort yang sama dengan request
+func decodeProductCursor(filter models.ProductFilter) (*productCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(filter.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor productCursor
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.Sort != filter.Sort || cursor.Desc != filter.Desc || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+func scanProduct(scanner interface{ Scan(...interface{}) error }, product *models.Product) error {
+	return scanner.Scan(&product.ID, &product.SKU, pq.Array(&product.Barcodes), &product.Name, &product.Description, &product.Price, &product.CostPrice, &product.Stock, &product.ReorderPoint, &product.ReorderQty, &product.PictureURL, &product.CategoryID, &product.CategoryName, &product.CreatedAt)
+}
+
 type ProductRepository struct {
 	db *sql.DB
 }
@@ -17,45 +76,212 @@ func NewProductRepository(db *sql.DB) *ProductRepository {
 	}
 }
 
-func (r *ProductRepository) GetAllProducts(name string) ([]models.Product, error) {
-	var products []models.Product
-
+// productConditions menyusun kondisi WHERE dari filter daftar produk
+func productConditions(filter models.ProductFilter) ([]string, []interface{}) {
+	conditions := []string{}
 	args := []interface{}{}
-	query := "SELECT products.id, products.name, products.description, products.price, products.stock, products.picture_url, categories.id as category_id, categories.name as category_name FROM products LEFT JOIN categories ON products.category_id = categories.id"
-	if name != "" {
-		query += " WHERE products.name ILIKE $1"
-		args = append(args, "%"+name+"%")
+
+	if filter.Name != "" {
+		args = append(args, "%"+filter.Name+"%")
+		conditions = append(conditions, fmt.Sprintf("products.name ILIKE $%d", len(args)))
+	}
+	if filter.CategoryID != "" {
+		args = append(args, filter.CategoryID)
+		conditions = append(conditions, fmt.Sprintf("products.category_id = $%d", len(args)))
+	}
+	if filter.MinPrice != nil {
+		args = append(args, *filter.MinPrice)
+		conditions = append(conditions, fmt.Sprintf("products.price >= $%d", len(args)))
+	}
+	if filter.MaxPrice != nil {
+		args = append(args, *filter.MaxPrice)
+		conditions = append(conditions, fmt.Sprintf("products.price <= $%d", len(args)))
+	}
+	if filter.LowStock != nil {
+		args = append(args, *filter.LowStock)
+		conditions = append(conditions, fmt.Sprintf("products.stock <= $%d", len(args)))
+	}
+
+	return conditions, args
+}
+
+// GetAllProducts mengembalikan satu halaman produk, total produk yang cocok
+// dengan filter, dan cursor untuk halaman berikutnya (kosong jika sudah habis).
+func (r *ProductRepository) GetAllProducts(filter models.ProductFilter) ([]models.Product, int, string, error) {
+	sortColumn, ok := productSortColumns[filter.Sort]
+	if !ok {
+		return nil, 0, "", apperror.ErrInvalidQuery.WithMessage(fmt.Sprintf("sort %q tidak didukung", filter.Sort))
 	}
-	rows, err := r.db.Query(query, args...)
 
+	conditions, args := productConditions(filter)
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM products"+where, args...).Scan(&total); err != nil {
+		return nil, 0, "", err
+	}
+
+	direction, comparator := "ASC", ">"
+	if filter.Desc {
+		direction, comparator = "DESC", "<"
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := decodeProductCursor(filter)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		args = append(args, cursor.Value, cursor.ID)
+		conditions = append(conditions, fmt.Sprintf("(%s, products.id) %s ($%d, $%d)", sortColumn, comparator, len(args)-1, len(args)))
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// ambil satu baris lebih untuk mengetahui apakah masih ada halaman berikutnya
+	args = append(args, filter.Limit+1)
+	query := productSelect + where + fmt.Sprintf(" ORDER BY %s %s, products.id %s LIMIT $%d", sortColumn, direction, direction, len(args))
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, 0, "", err
 	}
 	defer rows.Close()
 
+	products := make([]models.Product, 0)
 	for rows.Next() {
 		var product models.Product
-		if err := rows.Scan(&product.ID, &product.Name, &product.Description, &product.Price, &product.Stock, &product.PictureURL, &product.CategoryID, &product.CategoryName); err != nil {
-			return nil, err
+		if err := scanProduct(rows, &product); err != nil {
+			return nil, 0, "", err
 		}
 		products = append(products, product)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, 0, "", err
+	}
+
+	nextCursor := ""
+	if len(products) > filter.Limit {
+		products = products[:filter.Limit]
+		nextCursor = encodeProductCursor(filter, products[len(products)-1])
+	}
+
+	return products, total, nextCursor, nil
+}
+
+// ExportProducts memanggil fn untuk setiap produk yang cocok dengan filter
+// tanpa pagination, baris demi baris, sehingga daftar produk tidak perlu
+// dimuat seluruhnya ke memori.
+func (r *ProductRepository) ExportProducts(filter models.ProductFilter, fn func(models.Product) error) error {
+	sortColumn, ok := productSortColumns[filter.Sort]
+	if !ok {
+		return apperror.ErrInvalidQuery.WithMessage(fmt.Sprintf("sort %q tidak didukung", filter.Sort))
+	}
+
+	conditions, args := productConditions(filter)
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
+
+	rows, err := r.db.Query(productSelect+where+fmt.Sprintf(" ORDER BY %s %s, products.id %s", sortColumn, direction, direction), args...)
+	if err != nil {
+		return err
 	}
+	defer rows.Close()
 
-	return products, nil
+	for rows.Next() {
+		var product models.Product
+		if err := scanProduct(rows, &product); err != nil {
+			return err
+		}
+		if err := fn(product); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
 func (r *ProductRepository) GetProductByID(id string) (*models.Product, error) {
-	var product models.Product
+	return getProduct(r.db, id, false)
+}
+
+// GetProductByBarcode mencari produk berdasarkan barcode EAN-13 yang sudah
+// dinormalisasi
+func (r *ProductRepository) GetProductByBarcode(barcode string) (*models.Product, error) {
+	return findProduct(r.db.QueryRow(productSelect+" WHERE products.id = (SELECT product_id FROM product_barcodes WHERE barcode = $1)", barcode))
+}
 
-	row := r.db.QueryRow("SELECT products.id, products.name, products.description, products.price, products.stock, products.picture_url, categories.id as category_id, categories.name as category_name FROM products LEFT JOIN categories ON products.category_id = categories.id WHERE products.id = $1", id)
+func (r *ProductRepository) GetProductBySKU(sku string) (*models.Product, error) {
+	return findProduct(r.db.QueryRow(productSelect+" WHERE products.sku = $1", sku))
+}
 
-	if err := row.Scan(&product.ID, &product.Name, &product.Description, &product.Price, &product.Stock, &product.PictureURL, &product.CategoryID, &product.CategoryName); err != nil {
+func findProduct(row *sql.Row) (*models.Product, error) {
+	var product models.Product
+	if err := scanProduct(row, &product); err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("produk tidak ditemukan")
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+// setProductBarcodes mengganti seluruh barcode produk. Barcode yang sudah
+// dipakai produk lain ditolak dengan ErrBarcodeExists.
+func setProductBarcodes(tx *sql.Tx, productID string, barcodes []string) error {
+	if _, err := tx.Exec("DELETE FROM product_barcodes WHERE product_id = $1", productID); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO product_barcodes (barcode, product_id) VALUES ($1, $2) ON CONFLICT (barcode) DO NOTHING")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, barcode := range barcodes {
+		result, err := stmt.Exec(barcode, productID)
+		if err != nil {
+			return err
+		}
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if inserted == 0 {
+			return fmt.Errorf("%w: %s", ErrBarcodeExists, barcode)
+		}
+	}
+	return nil
+}
+
+// queryRower dipenuhi oleh *sql.DB dan *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// getProduct membaca satu produk. forUpdate mengunci baris produk sampai
+// transaksi database selesai, dipakai untuk mengambil data sebelum diubah.
+func getProduct(q queryRower, id string, forUpdate bool) (*models.Product, error) {
+	var product models.Product
+
+	query := productSelect + " WHERE products.id = $1"
+	if forUpdate {
+		query += " FOR UPDATE OF products"
+	}
+	row := q.QueryRow(query, id)
+
+	if err := scanProduct(row, &product); err != nil {
+		if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+			return nil, ErrProductNotFound
 		}
 		return nil, err
 	}
@@ -63,50 +289,146 @@ func (r *ProductRepository) GetProductByID(id string) (*models.Product, error) {
 	return &product, nil
 }
 
-func (r *ProductRepository) CreateProduct(product *models.Product) error {
-	err := r.db.QueryRow("INSERT INTO products (name, description, price, stock, category_id, picture_url) VALUES ($1, $2, $3, $4, $5, $6) returning id, category_id", product.Name, product.Description, product.Price, product.Stock, product.CategoryID, product.PictureURL).Scan(&product.ID, &product.CategoryID)
+func (r *ProductRepository) CreateProduct(product *models.Product, actor *models.AuthUser) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
+	var id string
+	err = tx.QueryRow("INSERT INTO products (sku, name, description, price, cost_price, stock, reorder_point, reorder_qty, category_id, picture_url) VALUES (NULLIF($1, ''), $2, $3, $4, COALESCE($5, 0), 0, COALESCE($6, 0), COALESCE($7, 0), $8, $9) returning id", product.SKU, product.Name, product.Description, product.Price, product.CostPrice, product.ReorderPoint, product.ReorderQty, product.CategoryID, product.PictureURL).Scan(&id)
+	if isForeignKeyViolation(err) || isInvalidTextRepresentation(err) {
+		return ErrInvalidCategory
+	}
+	if isUniqueViolation(err) {
+		return ErrSKUExists
+	}
 	if err != nil {
 		return err
 	}
 
-	err = r.db.QueryRow("SELECT name FROM categories WHERE id = $1", product.CategoryID).Scan(&product.CategoryName)
+	if len(product.Barcodes) > 0 {
+		if err := setProductBarcodes(tx, id, product.Barcodes); err != nil {
+			return err
+		}
+	}
+
+	if product.Stock != nil && *product.Stock != 0 {
+		reason := "stok awal"
+		_, err = applyStockMovement(tx, &models.StockMovement{
+			ProductID: id,
+			Type:      models.StockMovementAdjustment,
+			Quantity:  *product.Stock,
+			Reason:    &reason,
+		}, actor)
+		if err != nil {
+			return err
+		}
+	}
+
+	created, err := getProduct(tx, id, false)
 	if err != nil {
 		return err
 	}
+	if err := insertAuditLog(tx, actor, models.AuditEntityProduct, id, models.AuditActionCreate, nil, created); err != nil {
+		return err
+	}
 
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	*product = *created
 	return nil
 }
 
-func (r *ProductRepository) UpdateProduct(product *models.Product) error {
-	result, err := r.db.Exec("UPDATE products SET name = $1, description = $2, price = $3, stock = $4, category_id = $5, picture_url = COALESCE($6, picture_url) WHERE id = $7", product.Name, product.Description, product.Price, product.Stock, product.CategoryID, product.PictureURL, product.ID)
+func (r *ProductRepository) UpdateProduct(product *models.Product, actor *models.AuthUser) error {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	before, err := getProduct(tx, product.ID, true)
+	if err != nil {
+		return err
+	}
+
+	// sku NULL mempertahankan nilai lama, string kosong menghapusnya
+	_, err = tx.Exec("UPDATE products SET name = $1, description = $2, price = $3, cost_price = COALESCE($4, cost_price), reorder_point = COALESCE($5, reorder_point), reorder_qty = COALESCE($6, reorder_qty), category_id = $7, picture_url = COALESCE($8, picture_url), sku = CASE WHEN $9::text IS NULL THEN sku ELSE NULLIF($9, '') END WHERE id = $10", product.Name, product.Description, product.Price, product.CostPrice, product.ReorderPoint, product.ReorderQty, product.CategoryID, product.PictureURL, product.SKU, product.ID)
+	if isForeignKeyViolation(err) || isInvalidTextRepresentation(err) {
+		return ErrInvalidCategory
+	}
+	if isUniqueViolation(err) {
+		return ErrSKUExists
+	}
+	if err != nil {
+		return err
+	}
+
+	if product.Barcodes != nil {
+		if err := setProductBarcodes(tx, product.ID, product.Barcodes); err != nil {
+			return err
+		}
+	}
 
-	rows, err := result.RowsAffected()
+	// stock yang tidak dikirim tidak mengubah stok
+	if product.Stock == nil {
+		product.Stock = before.Stock
+	}
+	if delta := *product.Stock - *before.Stock; delta != 0 {
+		if product.StockReason == "" {
+			return ErrStockReasonRequired
+		}
+		_, err = applyStockMovement(tx, &models.StockMovement{
+			ProductID: product.ID,
+			Type:      models.StockMovementAdjustment,
+			Quantity:  delta,
+			Reason:    &product.StockReason,
+		}, actor)
+		if err != nil {
+			return err
+		}
+	}
+
+	after, err := getProduct(tx, product.ID, false)
 	if err != nil {
 		return err
 	}
+	if err := insertAuditLog(tx, actor, models.AuditEntityProduct, product.ID, models.AuditActionUpdate, before, after); err != nil {
+		return err
+	}
 
-	if rows == 0 {
-		return errors.New("produk tidak ditemukan")
+	if err := tx.Commit(); err != nil {
+		return err
 	}
+	*product = *after
 	return nil
 }
 
-func (r *ProductRepository) DeleteProduct(id string) error {
-	result, err := r.db.Exec("DELETE FROM products WHERE id = $1", id)
+func (r *ProductRepository) DeleteProduct(id string, actor *models.AuthUser) error {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
-	rows, err := result.RowsAffected()
+	defer tx.Rollback()
+
+	before, err := getProduct(tx, id, true)
 	if err != nil {
 		return err
 	}
 
-	if rows == 0 {
-		return errors.New("produk tidak ditemukan")
+	_, err = tx.Exec("DELETE FROM products WHERE id = $1", id)
+	if isForeignKeyViolation(err) {
+		return ErrProductInUse
 	}
-	return nil
+	if err != nil {
+		return err
+	}
+
+	if err := insertAuditLog(tx, actor, models.AuditEntityProduct, id, models.AuditActionDelete, before, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
@@ -0,0 +1,239 @@
+package repositories
+
+import (
+	"database/sql"
+	"sort"
+
+	"labkoding.my.id/kasir-api/models"
+)
+
+const promotionColumns = "id, name, type, scope, product_id, category_id, value, buy_qty, get_qty, min_spend, starts_at, ends_at, active, created_at::timestamptz"
+
+type PromotionRepository struct {
+	db *sql.DB
+}
+
+func NewPromotionRepository(db *sql.DB) *PromotionRepository {
+	return &PromotionRepository{
+		db: db,
+	}
+}
+
+func scanPromotion(scanner interface{ Scan(...interface{}) error }, promotion *models.Promotion) error {
+	return scanner.Scan(&promotion.ID, &promotion.Name, &promotion.Type, &promotion.Scope, &promotion.ProductID, &promotion.CategoryID, &promotion.Value, &promotion.BuyQty, &promotion.GetQty, &promotion.MinSpend, &promotion.StartsAt, &promotion.EndsAt, &promotion.Active, &promotion.CreatedAt)
+}
+
+func (r *PromotionRepository) GetAllPromotions(activeOnly bool) ([]models.Promotion, error) {
+	query := "SELECT " + promotionColumns + " FROM promotions"
+	if activeOnly {
+		query += " WHERE " + activePromotionCondition
+	}
+	query += " ORDER BY created_at DESC"
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := make([]models.Promotion, 0)
+	for rows.Next() {
+		var promotion models.Promotion
+		if err := scanPromotion(rows, &promotion); err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, promotion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+}
+
+func (r *PromotionRepository) GetPromotionByID(id string) (*models.Promotion, error) {
+	var promotion models.Promotion
+
+	row := r.db.QueryRow("SELECT "+promotionColumns+" FROM promotions WHERE id = $1", id)
+	if err := scanPromotion(row, &promotion); err != nil {
+		if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+			return nil, ErrPromotionNotFound
+		}
+		return nil, err
+	}
+
+	return &promotion, nil
+}
+
+func (r *PromotionRepository) CreatePromotion(promotion *models.Promotion) error {
+	err := r.db.QueryRow("INSERT INTO promotions (name, type, scope, product_id, category_id, value, buy_qty, get_qty, min_spend, starts_at, ends_at, active) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id, created_at::timestamptz",
+		promotion.Name, promotion.Type, promotion.Scope, promotion.ProductID, promotion.CategoryID, promotion.Value, promotion.BuyQty, promotion.GetQty, promotion.MinSpend, promotion.StartsAt, promotion.EndsAt, promotion.Active,
+	).Scan(&promotion.ID, &promotion.CreatedAt)
+	if isForeignKeyViolation(err) {
+		return invalidPromotionTarget(promotion)
+	}
+	return err
+}
+
+func (r *PromotionRepository) UpdatePromotion(promotion *models.Promotion) error {
+	err := r.db.QueryRow("UPDATE promotions SET name = $1, type = $2, scope = $3, product_id = $4, category_id = $5, value = $6, buy_qty = $7, get_qty = $8, min_spend = $9, starts_at = $10, ends_at = $11, active = $12 WHERE id = $13 RETURNING created_at::timestamptz",
+		promotion.Name, promotion.Type, promotion.Scope, promotion.ProductID, promotion.CategoryID, promotion.Value, promotion.BuyQty, promotion.GetQty, promotion.MinSpend, promotion.StartsAt, promotion.EndsAt, promotion.Active, promotion.ID,
+	).Scan(&promotion.CreatedAt)
+	if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+		return ErrPromotionNotFound
+	}
+	if isForeignKeyViolation(err) {
+		return invalidPromotionTarget(promotion)
+	}
+	return err
+}
+
+// invalidPromotionTarget mengembalikan error untuk product_id atau category_id
+// yang tidak ditemukan. Service hanya mengisi salah satunya sesuai scope.
+func invalidPromotionTarget(promotion *models.Promotion) error {
+	if promotion.Scope == models.PromotionScopeCategory {
+		return ErrInvalidCategory
+	}
+	return ErrInvalidProduct
+}
+
+func (r *PromotionRepository) DeletePromotion(id string) error {
+	result, err := r.db.Exec("DELETE FROM promotions WHERE id = $1", id)
+	if isInvalidTextRepresentation(err) {
+		return ErrPromotionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrPromotionNotFound
+	}
+	return nil
+}
+
+// activePromotionCondition memfilter promosi yang aktif dan berada dalam masa berlakunya
+const activePromotionCondition = "active = TRUE AND (starts_at IS NULL OR starts_at <= NOW()) AND (ends_at IS NULL OR ends_at >= NOW())"
+
+// activePromotions memuat promosi yang berlaku di dalam transaksi checkout
+func activePromotions(tx *sql.Tx) ([]models.Promotion, error) {
+	rows, err := tx.Query("SELECT " + promotionColumns + " FROM promotions WHERE " + activePromotionCondition + " ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := make([]models.Promotion, 0)
+	for rows.Next() {
+		var promotion models.Promotion
+		if err := scanPromotion(rows, &promotion); err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, promotion)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+}
+
+// applyPromotions memilih diskon terbesar untuk setiap baris (promosi tidak
+// ditumpuk dalam satu baris), lalu memilih diskon keranjang terbesar yang
+// syarat minimal belanjanya terpenuhi. Diskon keranjang dibagi ke setiap baris
+// sebanding dengan nilai bersihnya supaya refund per baris tetap akurat.
+// Sisa pembulatan dibagikan dengan metode sisa terbesar sehingga bagian
+// setiap baris tidak pernah melebihi nilai bersihnya.
+// Mengembalikan promosi keranjang yang dipakai, atau nil.
+func applyPromotions(lines []pricedLine, promotions []models.Promotion) *string {
+	for i := range lines {
+		for _, promotion := range promotions {
+			if !promotionMatchesLine(promotion, lines[i]) {
+				continue
+			}
+			if discount := lineDiscount(promotion, lines[i]); discount > lines[i].discount {
+				lines[i].discount = discount
+				lines[i].promotionID = &promotion.ID
+			}
+		}
+	}
+
+	net := 0
+	for _, line := range lines {
+		net += line.net()
+	}
+
+	var cartPromotionID *string
+	cartDiscount := 0
+	for _, promotion := range promotions {
+		if promotion.Scope != models.PromotionScopeCart || net < promotion.MinSpend {
+			continue
+		}
+		discount := 0
+		switch promotion.Type {
+		case models.PromotionTypePercentage:
+			discount = net * promotion.Value / 100
+		case models.PromotionTypeFixed:
+			discount = min(promotion.Value, net)
+		}
+		if discount > cartDiscount {
+			cartDiscount = discount
+			cartPromotionID = &promotion.ID
+		}
+	}
+
+	if cartDiscount == 0 {
+		return nil
+	}
+
+	// bagian dasar dibulatkan ke bawah; baris dengan sisa pembagian terbesar
+	// mendapat tambahan 1 sampai seluruh diskon habis
+	remainders := make([]int, len(lines))
+	order := make([]int, len(lines))
+	remaining := cartDiscount
+	for i := range lines {
+		weighted := cartDiscount * lines[i].net()
+		remainders[i] = weighted % net
+		order[i] = i
+		lines[i].discount += weighted / net
+		remaining -= weighted / net
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	for _, i := range order[:remaining] {
+		lines[i].discount++
+	}
+
+	return cartPromotionID
+}
+
+func promotionMatchesLine(promotion models.Promotion, line pricedLine) bool {
+	switch promotion.Scope {
+	case models.PromotionScopeProduct:
+		return promotion.ProductID != nil && *promotion.ProductID == line.productID
+	case models.PromotionScopeCategory:
+		return promotion.CategoryID != nil && *promotion.CategoryID == line.categoryID
+	}
+	return false
+}
+
+func lineDiscount(promotion models.Promotion, line pricedLine) int {
+	switch promotion.Type {
+	case models.PromotionTypePercentage:
+		return line.gross() * promotion.Value / 100
+	case models.PromotionTypeFixed:
+		return min(promotion.Value, line.unitPrice) * line.quantity
+	case models.PromotionTypeBuyXGetY:
+		if promotion.BuyQty <= 0 || promotion.GetQty <= 0 {
+			return 0
+		}
+		free := line.quantity / (promotion.BuyQty + promotion.GetQty) * promotion.GetQty
+		return free * line.unitPrice
+	}
+	return 0
+}
@@ -0,0 +1,439 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lib/pq"
+	"labkoding.my.id/kasir-api/models"
+)
+
+const purchaseOrderSelect = "SELECT po.id, po.supplier_id, s.name, po.status, po.note, po.total_amount, po.created_by, po.created_at::timestamptz, po.ordered_at::timestamptz, po.closed_at::timestamptz FROM purchase_orders po JOIN suppliers s ON s.id = po.supplier_id"
+
+type PurchaseOrderRepository struct {
+	db         *sql.DB
+	costMethod string
+}
+
+func NewPurchaseOrderRepository(db *sql.DB, costMethod string) *PurchaseOrderRepository {
+	return &PurchaseOrderRepository{
+		db:         db,
+		costMethod: costMethod,
+	}
+}
+
+func scanPurchaseOrder(scanner interface{ Scan(...interface{}) error }, po *models.PurchaseOrder) error {
+	return scanner.Scan(&po.ID, &po.SupplierID, &po.SupplierName, &po.Status, &po.Note, &po.TotalAmount, &po.CreatedBy, &po.CreatedAt, &po.OrderedAt, &po.ClosedAt)
+}
+
+func (r *PurchaseOrderRepository) GetAllPurchaseOrders(filter models.PurchaseOrderFilter) ([]models.PurchaseOrder, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("po.status = $%d", len(args)))
+	}
+	if filter.SupplierID != "" {
+		args = append(args, filter.SupplierID)
+		conditions = append(conditions, fmt.Sprintf("po.supplier_id = $%d", len(args)))
+	}
+
+	query := purchaseOrderSelect
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY po.created_at DESC"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	purchaseOrders := make([]models.PurchaseOrder, 0)
+	ids := make([]string, 0)
+	for rows.Next() {
+		var po models.PurchaseOrder
+		if err := scanPurchaseOrder(rows, &po); err != nil {
+			return nil, err
+		}
+		po.Items = make([]models.PurchaseOrderItem, 0)
+		purchaseOrders = append(purchaseOrders, po)
+		ids = append(ids, po.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return purchaseOrders, nil
+	}
+
+	items, err := r.getItems(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range purchaseOrders {
+		purchaseOrders[i].Items = append(purchaseOrders[i].Items, items[purchaseOrders[i].ID]...)
+	}
+
+	return purchaseOrders, nil
+}
+
+func (r *PurchaseOrderRepository) GetPurchaseOrderByID(id string) (*models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	if err := scanPurchaseOrder(r.db.QueryRow(purchaseOrderSelect+" WHERE po.id = $1", id), &po); err != nil {
+		if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+			return nil, ErrPurchaseOrderNotFound
+		}
+		return nil, err
+	}
+
+	items, err := r.getItems([]string{id})
+	if err != nil {
+		return nil, err
+	}
+	po.Items = append(make([]models.PurchaseOrderItem, 0), items[id]...)
+
+	po.Receipts, err = r.getReceipts(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &po, nil
+}
+
+// getItems memuat item untuk beberapa purchase order sekaligus, dikelompokkan per id purchase order
+func (r *PurchaseOrderRepository) getItems(purchaseOrderIDs []string) (map[string][]models.PurchaseOrderItem, error) {
+	rows, err := r.db.Query("SELECT poi.purchase_order_id, poi.id, poi.product_id, COALESCE(p.name, ''), poi.quantity, poi.received_quantity, poi.cost_price FROM purchase_order_items poi LEFT JOIN products p ON p.id = poi.product_id WHERE poi.purchase_order_id = ANY($1::uuid[]) ORDER BY p.name, poi.id", pq.Array(purchaseOrderIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make(map[string][]models.PurchaseOrderItem)
+	for rows.Next() {
+		var purchaseOrderID string
+		var item models.PurchaseOrderItem
+		if err := rows.Scan(&purchaseOrderID, &item.ID, &item.ProductID, &item.ProductName, &item.Quantity, &item.ReceivedQuantity, &item.CostPrice); err != nil {
+			return nil, err
+		}
+		item.Subtotal = item.Quantity * item.CostPrice
+		items[purchaseOrderID] = append(items[purchaseOrderID], item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func (r *PurchaseOrderRepository) getReceipts(purchaseOrderID string) ([]models.GoodsReceipt, error) {
+	rows, err := r.db.Query("SELECT gr.id, gr.purchase_order_id, gr.note, gr.received_by, gr.created_at::timestamptz, gri.id, gri.product_id, gri.quantity, gri.cost_price FROM goods_receipts gr JOIN goods_receipt_items gri ON gri.goods_receipt_id = gr.id WHERE gr.purchase_order_id = $1 ORDER BY gr.created_at, gr.id, gri.id", purchaseOrderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	receipts := make([]models.GoodsReceipt, 0)
+	for rows.Next() {
+		var receipt models.GoodsReceipt
+		var item models.GoodsReceiptItem
+		if err := rows.Scan(&receipt.ID, &receipt.PurchaseOrderID, &receipt.Note, &receipt.ReceivedBy, &receipt.CreatedAt, &item.ID, &item.ProductID, &item.Quantity, &item.CostPrice); err != nil {
+			return nil, err
+		}
+		if n := len(receipts); n == 0 || receipts[n-1].ID != receipt.ID {
+			receipt.Items = make([]models.GoodsReceiptItem, 0)
+			receipts = append(receipts, receipt)
+		}
+		last := &receipts[len(receipts)-1]
+		last.Items = append(last.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return receipts, nil
+}
+
+// checkSupplier memastikan supplier ada dan masih aktif
+func checkSupplier(tx *sql.Tx, supplierID string) error {
+	var active bool
+	err := tx.QueryRow("SELECT active FROM suppliers WHERE id = $1", supplierID).Scan(&active)
+	if err == sql.ErrNoRows || isInvalidTextRepresentation(err) || (err == nil && !active) {
+		return ErrInvalidSupplier
+	}
+	return err
+}
+
+// insertItems menyimpan item purchase order dan mengembalikan total harga beli
+func insertItems(tx *sql.Tx, purchaseOrderID string, items []models.PurchaseOrderItemInput) (int, error) {
+	stmt, err := tx.Prepare("INSERT INTO purchase_order_items (purchase_order_id, product_id, quantity, cost_price) VALUES ($1, $2, $3, $4)")
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	total := 0
+	for _, item := range items {
+		if _, err := stmt.Exec(purchaseOrderID, item.ProductID, item.Quantity, item.CostPrice); err != nil {
+			if isForeignKeyViolation(err) {
+				return 0, fmt.Errorf("%w: %s", ErrInvalidProduct, item.ProductID)
+			}
+			return 0, err
+		}
+		total += item.Quantity * item.CostPrice
+	}
+
+	return total, nil
+}
+
+// lockPurchaseOrder mengunci purchase order dan mengembalikan statusnya
+func lockPurchaseOrder(tx *sql.Tx, id string) (string, error) {
+	var status string
+	err := tx.QueryRow("SELECT status FROM purchase_orders WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+		return "", ErrPurchaseOrderNotFound
+	}
+	return status, err
+}
+
+func (r *PurchaseOrderRepository) CreatePurchaseOrder(req models.PurchaseOrderRequest, actor *models.AuthUser) (string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	if err := checkSupplier(tx, req.SupplierID); err != nil {
+		return "", err
+	}
+
+	var createdBy *string
+	if actor != nil {
+		createdBy = &actor.ID
+	}
+
+	var id string
+	err = tx.QueryRow("INSERT INTO purchase_orders (supplier_id, note, created_by) VALUES ($1, $2, $3) RETURNING id", req.SupplierID, req.Note, createdBy).Scan(&id)
+	if err != nil {
+		return "", err
+	}
+
+	total, err := insertItems(tx, id, req.Items)
+	if err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec("UPDATE purchase_orders SET total_amount = $1 WHERE id = $2", total, id); err != nil {
+		return "", err
+	}
+
+	return id, tx.Commit()
+}
+
+// UpdatePurchaseOrder mengganti supplier, catatan dan seluruh item. Hanya
+// purchase order berstatus draft yang bisa diubah.
+func (r *PurchaseOrderRepository) UpdatePurchaseOrder(id string, req models.PurchaseOrderRequest) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	status, err := lockPurchaseOrder(tx, id)
+	if err != nil {
+		return err
+	}
+	if status != models.PurchaseOrderStatusDraft {
+		return ErrPurchaseOrderStatus
+	}
+	if err := checkSupplier(tx, req.SupplierID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM purchase_order_items WHERE purchase_order_id = $1", id); err != nil {
+		return err
+	}
+	total, err := insertItems(tx, id, req.Items)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE purchase_orders SET supplier_id = $1, note = $2, total_amount = $3 WHERE id = $4", req.SupplierID, req.Note, total, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateStatus memindahkan purchase order ke status baru jika status saat ini
+// termasuk dalam from
+func (r *PurchaseOrderRepository) UpdateStatus(id, status string, from ...string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	current, err := lockPurchaseOrder(tx, id)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(from, current) {
+		return ErrPurchaseOrderStatus
+	}
+
+	switch status {
+	case models.PurchaseOrderStatusOrdered:
+		_, err = tx.Exec("UPDATE purchase_orders SET status = $1, ordered_at = NOW() WHERE id = $2", status, id)
+	default:
+		_, err = tx.Exec("UPDATE purchase_orders SET status = $1, closed_at = NOW() WHERE id = $2", status, id)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReceiveGoods mencatat penerimaan barang, memperbarui HPP produk, menambah
+// stok produk lewat ledger stok dan memperbarui status purchase order menjadi
+// partially_received atau received.
+func (r *PurchaseOrderRepository) ReceiveGoods(id string, req models.GoodsReceiptRequest, actor *models.AuthUser) (*models.GoodsReceipt, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	status, err := lockPurchaseOrder(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if status != models.PurchaseOrderStatusOrdered && status != models.PurchaseOrderStatusPartiallyReceived {
+		return nil, ErrPurchaseOrderStatus
+	}
+
+	type orderedLine struct {
+		itemID    string
+		remaining int
+		costPrice int
+	}
+	rows, err := tx.Query("SELECT id, product_id, quantity - received_quantity, cost_price FROM purchase_order_items WHERE purchase_order_id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	lines := make(map[string]orderedLine)
+	for rows.Next() {
+		var productID string
+		var line orderedLine
+		if err := rows.Scan(&line.itemID, &productID, &line.remaining, &line.costPrice); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		lines[productID] = line
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, item := range req.Items {
+		line, ok := lines[item.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrPurchaseOrderItemNotFound, item.ProductID)
+		}
+		if item.Quantity > line.remaining {
+			return nil, fmt.Errorf("%w: produk %s hanya tersisa %d", ErrReceiptExceedsOrdered, item.ProductID, line.remaining)
+		}
+	}
+
+	receipt := models.GoodsReceipt{
+		PurchaseOrderID: id,
+		Note:            req.Note,
+		Items:           make([]models.GoodsReceiptItem, 0, len(req.Items)),
+	}
+	if actor != nil {
+		receipt.ReceivedBy = &actor.ID
+	}
+	err = tx.QueryRow("INSERT INTO goods_receipts (purchase_order_id, note, received_by) VALUES ($1, $2, $3) RETURNING id, created_at::timestamptz", id, receipt.Note, receipt.ReceivedBy).Scan(&receipt.ID, &receipt.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO goods_receipt_items (goods_receipt_id, purchase_order_item_id, product_id, quantity, cost_price) VALUES ($1, $2, $3, $4, $5) RETURNING id")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	for _, input := range req.Items {
+		line := lines[input.ProductID]
+		item := models.GoodsReceiptItem{ProductID: input.ProductID, Quantity: input.Quantity, CostPrice: line.costPrice}
+		if err := stmt.QueryRow(receipt.ID, line.itemID, item.ProductID, item.Quantity, item.CostPrice).Scan(&item.ID); err != nil {
+			return nil, err
+		}
+
+		if _, err := tx.Exec("UPDATE purchase_order_items SET received_quantity = received_quantity + $1 WHERE id = $2", item.Quantity, line.itemID); err != nil {
+			return nil, err
+		}
+
+		// HPP dihitung sebelum stok bertambah agar rata-rata memakai stok lama
+		if err := r.updateCostPrice(tx, item.ProductID, item.Quantity, item.CostPrice); err != nil {
+			return nil, err
+		}
+
+		_, err := applyStockMovement(tx, &models.StockMovement{
+			ProductID:   item.ProductID,
+			Type:        models.StockMovementPurchaseReceipt,
+			Quantity:    item.Quantity,
+			ReferenceID: &receipt.ID,
+		}, actor)
+		if err != nil {
+			return nil, err
+		}
+
+		receipt.Items = append(receipt.Items, item)
+	}
+
+	var outstanding int
+	if err := tx.QueryRow("SELECT COALESCE(SUM(quantity - received_quantity), 0) FROM purchase_order_items WHERE purchase_order_id = $1", id).Scan(&outstanding); err != nil {
+		return nil, err
+	}
+	if outstanding == 0 {
+		_, err = tx.Exec("UPDATE purchase_orders SET status = $1, closed_at = NOW() WHERE id = $2", models.PurchaseOrderStatusReceived, id)
+	} else {
+		_, err = tx.Exec("UPDATE purchase_orders SET status = $1 WHERE id = $2", models.PurchaseOrderStatusPartiallyReceived, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &receipt, nil
+}
+
+// updateCostPrice memperbarui HPP produk dari harga beli barang yang diterima.
+// Metode average menghitung rata-rata tertimbang dengan stok yang ada; jika
+// stok kosong atau minus, HPP langsung memakai harga beli.
+func (r *PurchaseOrderRepository) updateCostPrice(tx *sql.Tx, productID string, quantity, costPrice int) error {
+	var err error
+	switch r.costMethod {
+	case models.CostMethodLast:
+		_, err = tx.Exec("UPDATE products SET cost_price = $1 WHERE id = $2", costPrice, productID)
+	default:
+		_, err = tx.Exec(`
+			UPDATE products SET cost_price = CASE
+				WHEN stock <= 0 THEN $1
+				ELSE ROUND((stock::numeric * cost_price + $3::numeric * $1) / (stock + $3))
+			END
+			WHERE id = $2`, costPrice, productID, quantity)
+	}
+	return err
+}
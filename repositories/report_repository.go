@@ -2,74 +2,419 @@ package repositories
 
 import (
 	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
 
 	"labkoding.my.id/kasir-api/models"
 )
 
 type ReportRepository struct {
-	db *sql.DB
+	db  *sql.DB
+	loc *time.Location
 }
 
-func NewReportRepository(db *sql.DB) *ReportRepository {
+// NewReportRepository membuat ReportRepository; loc adalah zona waktu toko
+// yang dipakai untuk mengelompokkan data per hari dan per bucket
+func NewReportRepository(db *sql.DB, loc *time.Location) *ReportRepository {
 	return &ReportRepository{
-		db: db,
+		db:  db,
+		loc: loc,
 	}
 }
 
-func (r *ReportRepository) TodayReport() (models.Report, error) {
+// createdBetween membuat kondisi created_at dalam rentang [$1, $2) untuk tabel
+// dengan alias tersebut. created_at disimpan sebagai TIMESTAMP di zona waktu
+// sesi database, sehingga dibandingkan dengan timestamptz agar batas rentang
+// yang dihitung di zona waktu toko tetap tepat.
+func createdBetween(alias string) string {
+	return alias + ".created_at >= $1::timestamptz AND " + alias + ".created_at < $2::timestamptz"
+}
+
+// Summary merangkum penjualan untuk rentang [from, to). Refund dan void
+// mengurangi pendapatan pada periode refund dilakukan.
+func (r *ReportRepository) Summary(from, to time.Time, top int) (models.Report, error) {
 	var report models.Report
 
-	err := r.db.QueryRow("SELECT COALESCE(SUM(total_amount),0), COALESCE(COUNT(*),0) as total_transaction FROM transactions WHERE DATE(created_at) = CURRENT_DATE").Scan(&report.TotalRevenue, &report.TotalTransactions)
+	err := r.db.QueryRow("SELECT COALESCE(SUM(t.total_amount),0), COUNT(*) FILTER (WHERE t.status <> 'voided') as total_transaction FROM transactions t WHERE "+createdBetween("t"), from, to).Scan(&report.TotalRevenue, &report.TotalTransactions)
+	if err != nil {
+		return models.Report{}, err
+	}
+
+	err = r.db.QueryRow("SELECT COALESCE(SUM(rf.total_amount),0) FROM refunds rf WHERE "+createdBetween("rf"), from, to).Scan(&report.TotalRefunds)
+	if err != nil {
+		return models.Report{}, err
+	}
+	report.TotalRevenue -= report.TotalRefunds
+
+	err = r.bestSellers(&report, top, createdBetween("t"), createdBetween("rf"), from, to)
 	if err != nil {
 		return models.Report{}, err
 	}
 
-	rows, err := r.db.Query("SELECT p.name, COALESCE(SUM(td.quantity),0) FROM transaction_details td JOIN products p ON td.product_id = p.id WHERE td.transaction_id IN (SELECT id FROM transactions WHERE DATE(created_at) = CURRENT_DATE) GROUP BY p.name ORDER BY SUM(td.quantity) DESC LIMIT 1")
+	report.PaymentMethods, err = r.paymentMethods(createdBetween("t"), createdBetween("rf"), from, to)
 	if err != nil {
 		return models.Report{}, err
 	}
+
+	err = r.margins(&report, createdBetween("t"), createdBetween("rf"), from, to)
+	if err != nil {
+		return models.Report{}, err
+	}
+
+	return report, nil
+}
+
+// bestSellers menyusun peringkat top produk dan kategori berdasarkan quantity
+// terjual, dikelompokkan per id. Revenue adalah subtotal baris (termasuk PPN
+// dan service charge) dikurangi nilai refund pada periode yang sama, dan
+// share dihitung terhadap total seluruh produk pada periode tersebut.
+func (r *ReportRepository) bestSellers(report *models.Report, top int, transactionCondition, refundCondition string, args ...interface{}) error {
+	rows, err := r.db.Query(`
+		SELECT s.product_id, COALESCE(p.name, ''), p.category_id, COALESCE(c.name, ''),
+			SUM(s.quantity)::bigint, SUM(s.revenue)::bigint
+		FROM (
+			SELECT td.product_id, td.quantity, td.subtotal AS revenue
+			FROM transaction_details td
+			JOIN transactions t ON t.id = td.transaction_id
+			WHERE `+transactionCondition+`
+			UNION ALL
+			SELECT rd.product_id, -rd.quantity, -rd.amount
+			FROM refund_details rd
+			JOIN refunds rf ON rf.id = rd.refund_id
+			WHERE `+refundCondition+`
+		) s
+		LEFT JOIN products p ON p.id = s.product_id
+		LEFT JOIN categories c ON c.id = p.category_id
+		GROUP BY s.product_id, p.name, p.category_id, c.name
+		HAVING SUM(s.quantity) > 0
+		ORDER BY SUM(s.quantity) DESC, SUM(s.revenue) DESC, p.name`, args...)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 
+	products := make([]models.BestSellingProduct, 0)
+	categories := make([]models.BestSellingCategory, 0)
+	categoryIndex := make(map[string]int)
+	totalQty, totalRevenue := 0, 0
 	for rows.Next() {
-		var productName string
-		var quantity int
-		if err := rows.Scan(&productName, &quantity); err != nil {
-			return models.Report{}, err
+		var product models.BestSellingProduct
+		var categoryName string
+		if err := rows.Scan(&product.ProductID, &product.Name, &product.CategoryID, &categoryName, &product.QtySold, &product.Revenue); err != nil {
+			return err
 		}
-		report.BestSellingProducts = models.BestSellingProduct{
-			Name:    productName,
-			QtySold: quantity,
+		products = append(products, product)
+		totalQty += product.QtySold
+		totalRevenue += product.Revenue
+
+		key := ""
+		if product.CategoryID != nil {
+			key = *product.CategoryID
+		}
+		i, ok := categoryIndex[key]
+		if !ok {
+			i = len(categories)
+			categoryIndex[key] = i
+			categories = append(categories, models.BestSellingCategory{CategoryID: product.CategoryID, Name: categoryName})
 		}
+		categories[i].QtySold += product.QtySold
+		categories[i].Revenue += product.Revenue
+	}
+	if err := rows.Err(); err != nil {
+		return err
 	}
 
-	return report, nil
+	sort.SliceStable(categories, func(i, j int) bool {
+		if categories[i].QtySold != categories[j].QtySold {
+			return categories[i].QtySold > categories[j].QtySold
+		}
+		return categories[i].Revenue > categories[j].Revenue
+	})
+
+	if len(products) > top {
+		products = products[:top]
+	}
+	if len(categories) > top {
+		categories = categories[:top]
+	}
+	for i := range products {
+		products[i].Rank = i + 1
+		products[i].QtySharePercent = percentOf(products[i].QtySold, totalQty)
+		products[i].RevenueSharePercent = percentOf(products[i].Revenue, totalRevenue)
+	}
+	for i := range categories {
+		categories[i].Rank = i + 1
+		categories[i].QtySharePercent = percentOf(categories[i].QtySold, totalQty)
+		categories[i].RevenueSharePercent = percentOf(categories[i].Revenue, totalRevenue)
+	}
+
+	report.BestSellingProducts = products
+	report.BestSellingCategories = categories
+	return nil
 }
 
-func (r *ReportRepository) Range(startDate, endDate string) (models.Report, error) {
-	var report models.Report
+// percentOf menghitung part terhadap total dalam persen, dibulatkan dua desimal
+func percentOf(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(part)/float64(total)*10000) / 100
+}
+
+// paymentMethods merangkum pembayaran per metode dengan aturan yang sama
+// seperti TotalRevenue: penjualan dihitung pada periode checkout (termasuk
+// transaksi yang kemudian di-void) dan refund/void mengurangi metode
+// pembayarannya pada periode refund dilakukan, sehingga jumlah seluruh metode
+// sama dengan TotalRevenue. Seperti Z-report shift, refund dianggap
+// dikembalikan tunai lebih dulu, lalu lewat metode lain sesuai urutan
+// pembayaran. TotalTransactions tidak menghitung transaksi yang dibatalkan.
+func (r *ReportRepository) paymentMethods(transactionCondition, refundCondition string, args ...interface{}) ([]models.PaymentMethodSummary, error) {
+	args = append(args, models.PaymentMethodCash)
+	cash := fmt.Sprintf("$%d", len(args))
 
-	err := r.db.QueryRow("SELECT COALESCE(SUM(total_amount),0), COALESCE(COUNT(*),0) as total_transaction FROM transactions WHERE DATE(created_at) between $1 and $2", startDate, endDate).Scan(&report.TotalRevenue, &report.TotalTransactions)
+	rows, err := r.db.Query(`
+		WITH period_refunds AS (
+			SELECT r.transaction_id, r.total_amount, r.refunded_to
+			FROM (
+				SELECT rf.transaction_id, rf.total_amount, `+refundCondition+` AS in_period,
+					SUM(rf.total_amount) OVER (PARTITION BY rf.transaction_id ORDER BY rf.created_at, rf.id) AS refunded_to
+				FROM refunds rf
+				WHERE rf.transaction_id IN (SELECT rf.transaction_id FROM refunds rf WHERE `+refundCondition+`)
+			) r
+			WHERE r.in_period
+		),
+		payment_ranges AS (
+			SELECT tp.transaction_id, tp.method,
+				SUM(tp.amount) OVER w - tp.amount AS paid_from,
+				SUM(tp.amount) OVER w AS paid_to
+			FROM transaction_payments tp
+			WHERE tp.transaction_id IN (SELECT transaction_id FROM period_refunds)
+			WINDOW w AS (PARTITION BY tp.transaction_id ORDER BY tp.method <> `+cash+`, tp.line_no, tp.id)
+		)
+		SELECT m.method, COALESCE(SUM(m.amount), 0)::bigint, COUNT(DISTINCT m.transaction_id) FILTER (WHERE m.counted)
+		FROM (
+			SELECT tp.method, tp.amount, tp.transaction_id, t.status <> 'voided' AS counted
+			FROM transaction_payments tp
+			JOIN transactions t ON t.id = tp.transaction_id
+			WHERE `+transactionCondition+`
+			UNION ALL
+			SELECT p.method, -GREATEST(LEAST(p.paid_to, pr.refunded_to) - GREATEST(p.paid_from, pr.refunded_to - pr.total_amount), 0), p.transaction_id, FALSE
+			FROM period_refunds pr
+			JOIN payment_ranges p ON p.transaction_id = pr.transaction_id
+		) m
+		GROUP BY m.method
+		ORDER BY m.method`, args...)
 	if err != nil {
-		return models.Report{}, err
+		return nil, err
 	}
+	defer rows.Close()
 
-	rows, err := r.db.Query("SELECT p.name, COALESCE(SUM(td.quantity),0) FROM transaction_details td JOIN products p ON td.product_id = p.id WHERE td.transaction_id IN (SELECT id FROM transactions WHERE DATE(created_at) between $1 and $2) GROUP BY p.name ORDER BY SUM(td.quantity) DESC LIMIT 1", startDate, endDate)
+	summaries := make([]models.PaymentMethodSummary, 0)
+	for rows.Next() {
+		var summary models.PaymentMethodSummary
+		if err := rows.Scan(&summary.Method, &summary.TotalAmount, &summary.TotalTransactions); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, summary)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return summaries, nil
+}
+
+// margins menghitung penjualan bersih, HPP dan laba kotor per produk, lalu
+// menjumlahkannya per kategori dan untuk seluruh periode. Penjualan bersih
+// adalah subtotal tanpa PPN; refund mengurangi penjualan secara proporsional
+// dan HPP sesuai snapshot HPP baris yang direfund.
+func (r *ReportRepository) margins(report *models.Report, transactionCondition, refundCondition string, args ...interface{}) error {
+	rows, err := r.db.Query(`
+		SELECT s.product_id, COALESCE(p.name, ''), p.category_id, COALESCE(c.name, ''),
+			SUM(s.quantity)::bigint, SUM(s.net_sales)::bigint, SUM(s.cogs)::bigint
+		FROM (
+			SELECT td.product_id, td.quantity,
+				(td.subtotal - td.tax_amount)::numeric AS net_sales,
+				(td.cost_price * td.quantity)::numeric AS cogs
+			FROM transaction_details td
+			JOIN transactions t ON t.id = td.transaction_id
+			WHERE `+transactionCondition+`
+			UNION ALL
+			SELECT td.product_id, -rd.quantity,
+				-ROUND((td.subtotal - td.tax_amount) * rd.quantity::numeric / td.quantity),
+				-(td.cost_price * rd.quantity)::numeric
+			FROM refund_details rd
+			JOIN refunds rf ON rf.id = rd.refund_id
+			JOIN transaction_details td ON td.id = rd.transaction_detail_id
+			WHERE `+refundCondition+`
+		) s
+		LEFT JOIN products p ON p.id = s.product_id
+		LEFT JOIN categories c ON c.id = p.category_id
+		GROUP BY s.product_id, p.name, p.category_id, c.name
+		ORDER BY SUM(s.net_sales) - SUM(s.cogs) DESC, p.name`, args...)
 	if err != nil {
-		return models.Report{}, err
+		return err
 	}
 	defer rows.Close()
 
+	report.ProductMargins = make([]models.ProductMargin, 0)
+	report.CategoryMargins = make([]models.CategoryMargin, 0)
+	categoryIndex := make(map[string]int)
 	for rows.Next() {
-		var productName string
-		var quantity int
-		if err := rows.Scan(&productName, &quantity); err != nil {
-			return models.Report{}, err
+		var product models.ProductMargin
+		var categoryName string
+		if err := rows.Scan(&product.ProductID, &product.ProductName, &product.CategoryID, &categoryName, &product.QtySold, &product.NetSales, &product.Cogs); err != nil {
+			return err
 		}
-		report.BestSellingProducts = models.BestSellingProduct{
-			Name:    productName,
-			QtySold: quantity,
+		product.MarginSummary = newMarginSummary(product.NetSales, product.Cogs)
+		report.ProductMargins = append(report.ProductMargins, product)
+
+		key := ""
+		if product.CategoryID != nil {
+			key = *product.CategoryID
+		}
+		i, ok := categoryIndex[key]
+		if !ok {
+			i = len(report.CategoryMargins)
+			categoryIndex[key] = i
+			report.CategoryMargins = append(report.CategoryMargins, models.CategoryMargin{CategoryID: product.CategoryID, CategoryName: categoryName})
 		}
+		report.CategoryMargins[i].QtySold += product.QtySold
+		report.CategoryMargins[i].NetSales += product.NetSales
+		report.CategoryMargins[i].Cogs += product.Cogs
+
+		report.Margin.NetSales += product.NetSales
+		report.Margin.Cogs += product.Cogs
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range report.CategoryMargins {
+		category := &report.CategoryMargins[i]
+		category.MarginSummary = newMarginSummary(category.NetSales, category.Cogs)
+	}
+	sort.SliceStable(report.CategoryMargins, func(i, j int) bool {
+		return report.CategoryMargins[i].GrossProfit > report.CategoryMargins[j].GrossProfit
+	})
+	report.Margin = newMarginSummary(report.Margin.NetSales, report.Margin.Cogs)
+
+	return nil
+}
+
+// newMarginSummary menghitung laba kotor dan persentase margin terhadap
+// penjualan bersih
+func newMarginSummary(netSales, cogs int) models.MarginSummary {
+	summary := models.MarginSummary{
+		NetSales:    netSales,
+		Cogs:        cogs,
+		GrossProfit: netSales - cogs,
+	}
+	summary.GrossMarginPercent = percentOf(summary.GrossProfit, netSales)
+	return summary
+}
+
+// TaxReport merangkum dasar pengenaan pajak, service charge dan PPN per hari
+// di zona waktu toko untuk rentang [from, to). Refund mengurangi pajak secara
+// proporsional pada hari refund dilakukan.
+func (r *ReportRepository) TaxReport(from, to time.Time) (models.TaxReport, error) {
+	report := models.TaxReport{
+		Days: make([]models.TaxReportRow, 0),
+	}
+
+	rows, err := r.db.Query(`
+		SELECT TO_CHAR(s.day, 'YYYY-MM-DD'), SUM(s.taxable)::bigint, SUM(s.service_charge)::bigint, SUM(s.tax)::bigint
+		FROM (
+			SELECT DATE(t.created_at::timestamptz AT TIME ZONE $3) AS day,
+				CASE WHEN td.tax_amount > 0 THEN td.subtotal - td.tax_amount ELSE 0 END::numeric AS taxable,
+				td.service_charge_amount::numeric AS service_charge,
+				td.tax_amount::numeric AS tax
+			FROM transaction_details td
+			JOIN transactions t ON t.id = td.transaction_id
+			WHERE `+createdBetween("t")+`
+			UNION ALL
+			SELECT DATE(rf.created_at::timestamptz AT TIME ZONE $3),
+				-ROUND(CASE WHEN td.tax_amount > 0 THEN td.subtotal - td.tax_amount ELSE 0 END * rd.quantity::numeric / td.quantity),
+				-ROUND(td.service_charge_amount * rd.quantity::numeric / td.quantity),
+				-ROUND(td.tax_amount * rd.quantity::numeric / td.quantity)
+			FROM refund_details rd
+			JOIN refunds rf ON rf.id = rd.refund_id
+			JOIN transaction_details td ON td.id = rd.transaction_detail_id
+			WHERE `+createdBetween("rf")+`
+		) s
+		GROUP BY s.day
+		ORDER BY s.day`, from, to, r.loc.String())
+	if err != nil {
+		return models.TaxReport{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.TaxReportRow
+		if err := rows.Scan(&row.Date, &row.TaxableAmount, &row.ServiceChargeAmount, &row.TaxAmount); err != nil {
+			return models.TaxReport{}, err
+		}
+		report.TaxableAmount += row.TaxableAmount
+		report.ServiceChargeAmount += row.ServiceChargeAmount
+		report.TaxAmount += row.TaxAmount
+		report.Days = append(report.Days, row)
+	}
+	if err := rows.Err(); err != nil {
+		return models.TaxReport{}, err
 	}
 
 	return report, nil
 }
+
+// TimeSeries merangkum penjualan per bucket waktu di zona waktu toko untuk
+// rentang [from, to). Hanya bucket yang memiliki data yang dikembalikan.
+// Revenue dan item terjual dikurangi refund pada bucket refund dilakukan;
+// transaksi yang dibatalkan tidak dihitung sebagai transaksi.
+func (r *ReportRepository) TimeSeries(granularity string, from, to time.Time) ([]models.TimeSeriesBucket, error) {
+	// cast ke timestamptz mengembalikan created_at ke waktu absolut sebelum
+	// dikonversi ke zona waktu toko
+	rows, err := r.db.Query(`
+		SELECT TO_CHAR(s.bucket, 'YYYY-MM-DD HH24:MI:SS'), SUM(s.revenue)::bigint, SUM(s.transactions)::bigint, SUM(s.items)::bigint
+		FROM (
+			SELECT DATE_TRUNC($3, t.created_at::timestamptz AT TIME ZONE $4) AS bucket,
+				t.total_amount AS revenue,
+				CASE WHEN t.status <> 'voided' THEN 1 ELSE 0 END AS transactions,
+				(SELECT COALESCE(SUM(td.quantity), 0) FROM transaction_details td WHERE td.transaction_id = t.id) AS items
+			FROM transactions t
+			WHERE `+createdBetween("t")+`
+			UNION ALL
+			SELECT DATE_TRUNC($3, rf.created_at::timestamptz AT TIME ZONE $4),
+				-rf.total_amount,
+				0,
+				-(SELECT COALESCE(SUM(rd.quantity), 0) FROM refund_details rd WHERE rd.refund_id = rf.id)
+			FROM refunds rf
+			WHERE `+createdBetween("rf")+`
+		) s
+		GROUP BY s.bucket
+		ORDER BY s.bucket`, from, to, granularity, r.loc.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := make([]models.TimeSeriesBucket, 0)
+	for rows.Next() {
+		var bucket models.TimeSeriesBucket
+		var bucketStart string
+		if err := rows.Scan(&bucketStart, &bucket.Revenue, &bucket.TransactionCount, &bucket.ItemsSold); err != nil {
+			return nil, err
+		}
+		bucket.Start, err = time.ParseInLocation("2006-01-02 15:04:05", bucketStart, r.loc)
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return buckets, nil
+}
@@ -0,0 +1,318 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"labkoding.my.id/kasir-api/models"
+)
+
+const shiftSelect = "SELECT s.id, s.cashier_id, COALESCE(u.username, ''), s.status, s.opening_float, s.note, s.opened_at::timestamptz, s.closed_by, s.closed_at::timestamptz, s.expected_cash, s.counted_cash FROM shifts s LEFT JOIN users u ON u.id = s.cashier_id"
+
+type ShiftRepository struct {
+	db *sql.DB
+}
+
+func NewShiftRepository(db *sql.DB) *ShiftRepository {
+	return &ShiftRepository{
+		db: db,
+	}
+}
+
+// queryer dipenuhi oleh *sql.DB dan *sql.Tx
+type queryer interface {
+	queryRower
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func scanShift(scanner interface{ Scan(...interface{}) error }, shift *models.Shift) error {
+	err := scanner.Scan(&shift.ID, &shift.CashierID, &shift.CashierUsername, &shift.Status, &shift.OpeningFloat, &shift.Note, &shift.OpenedAt, &shift.ClosedBy, &shift.ClosedAt, &shift.ExpectedCash, &shift.CountedCash)
+	if err != nil {
+		return err
+	}
+	if shift.ExpectedCash != nil && shift.CountedCash != nil {
+		variance := *shift.CountedCash - *shift.ExpectedCash
+		shift.Variance = &variance
+	}
+	return nil
+}
+
+func (r *ShiftRepository) OpenShift(req models.OpenShiftRequest, actor *models.AuthUser) (*models.Shift, error) {
+	shift := models.Shift{
+		CashierID:       actor.ID,
+		CashierUsername: actor.Username,
+		Status:          models.ShiftStatusOpen,
+		OpeningFloat:    req.OpeningFloat,
+		Note:            req.Note,
+	}
+
+	err := r.db.QueryRow("INSERT INTO shifts (cashier_id, opening_float, note) VALUES ($1, $2, $3) RETURNING id, opened_at::timestamptz", shift.CashierID, shift.OpeningFloat, shift.Note).Scan(&shift.ID, &shift.OpenedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrShiftAlreadyOpen
+		}
+		return nil, err
+	}
+
+	return &shift, nil
+}
+
+func (r *ShiftRepository) GetAllShifts(filter models.ShiftFilter) ([]models.Shift, error) {
+	conditions := []string{}
+	args := []interface{}{}
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		conditions = append(conditions, fmt.Sprintf("s.status = $%d", len(args)))
+	}
+	if filter.CashierID != "" {
+		args = append(args, filter.CashierID)
+		conditions = append(conditions, fmt.Sprintf("s.cashier_id = $%d", len(args)))
+	}
+
+	query := shiftSelect
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY s.opened_at DESC, s.id"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shifts := make([]models.Shift, 0)
+	for rows.Next() {
+		var shift models.Shift
+		if err := scanShift(rows, &shift); err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, shift)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return shifts, nil
+}
+
+func (r *ShiftRepository) GetShiftByID(id string) (*models.Shift, error) {
+	var shift models.Shift
+	if err := scanShift(r.db.QueryRow(shiftSelect+" WHERE s.id = $1", id), &shift); err != nil {
+		if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+			return nil, ErrShiftNotFound
+		}
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// GetOpenShiftID mengembalikan id shift open milik kasir
+func (r *ShiftRepository) GetOpenShiftID(cashierID string) (string, error) {
+	var id string
+	err := r.db.QueryRow("SELECT id FROM shifts WHERE cashier_id = $1 AND status = $2", cashierID, models.ShiftStatusOpen).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", ErrNoOpenShift
+	}
+	return id, err
+}
+
+// GetShiftReport menyusun Z-report dari data terkini. Untuk shift yang masih
+// open, ExpectedCash dihitung ulang; untuk shift closed dipakai nilai yang
+// disimpan saat penutupan.
+func (r *ShiftRepository) GetShiftReport(id string) (*models.ShiftReport, error) {
+	var report models.ShiftReport
+	if err := scanShift(r.db.QueryRow(shiftSelect+" WHERE s.id = $1", id), &report.Shift); err != nil {
+		if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+			return nil, ErrShiftNotFound
+		}
+		return nil, err
+	}
+
+	if err := shiftSummary(r.db, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// shiftSummary mengisi ringkasan penjualan, refund dan kas sebuah shift.
+// report.Shift harus sudah terisi.
+func shiftSummary(q queryer, report *models.ShiftReport) error {
+	id := report.ID
+
+	err := q.QueryRow("SELECT COUNT(*), COUNT(*) FILTER (WHERE status = $2), COALESCE(SUM(total_amount), 0) FROM transactions WHERE shift_id = $1", id, models.TransactionStatusVoided).Scan(&report.TransactionCount, &report.VoidCount, &report.GrossSales)
+	if err != nil {
+		return err
+	}
+
+	rows, err := q.Query("SELECT tp.method, COALESCE(SUM(tp.amount), 0), COUNT(DISTINCT tp.transaction_id) FROM transaction_payments tp JOIN transactions t ON t.id = tp.transaction_id WHERE t.shift_id = $1 GROUP BY tp.method ORDER BY tp.method", id)
+	if err != nil {
+		return err
+	}
+	report.PaymentMethods = make([]models.PaymentMethodSummary, 0)
+	for rows.Next() {
+		var summary models.PaymentMethodSummary
+		if err := rows.Scan(&summary.Method, &summary.TotalAmount, &summary.TotalTransactions); err != nil {
+			rows.Close()
+			return err
+		}
+		if summary.Method == models.PaymentMethodCash {
+			report.CashSales = summary.TotalAmount
+		}
+		report.PaymentMethods = append(report.PaymentMethods, summary)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// refund dianggap dikembalikan tunai lebih dulu, paling banyak sebesar
+	// pembayaran tunai transaksinya; sisanya lewat metode non-tunai
+	err = q.QueryRow(`
+		SELECT COALESCE(SUM(r.refund_count), 0), COALESCE(SUM(r.refunded), 0), COALESCE(SUM(LEAST(r.refunded, r.cash_paid)), 0)
+		FROM (
+			SELECT COUNT(*) AS refund_count, SUM(rf.total_amount) AS refunded,
+				(SELECT COALESCE(SUM(tp.amount), 0) FROM transaction_payments tp WHERE tp.transaction_id = rf.transaction_id AND tp.method = $2) AS cash_paid
+			FROM refunds rf
+			JOIN transactions t ON t.id = rf.transaction_id
+			WHERE t.shift_id = $1
+			GROUP BY rf.transaction_id
+		) r`, id, models.PaymentMethodCash).Scan(&report.RefundCount, &report.Refunds, &report.CashRefunds)
+	if err != nil {
+		return err
+	}
+	report.NetSales = report.GrossSales - report.Refunds
+
+	rows, err = q.Query("SELECT id, shift_id, type, amount, reason, created_by, created_at::timestamptz FROM shift_cash_movements WHERE shift_id = $1 ORDER BY created_at, id", id)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	report.CashMovements = make([]models.CashMovement, 0)
+	for rows.Next() {
+		var movement models.CashMovement
+		if err := rows.Scan(&movement.ID, &movement.ShiftID, &movement.Type, &movement.Amount, &movement.Reason, &movement.CreatedBy, &movement.CreatedAt); err != nil {
+			return err
+		}
+		if movement.Type == models.CashMovementIn {
+			report.CashIn += movement.Amount
+		} else {
+			report.CashOut += movement.Amount
+		}
+		report.CashMovements = append(report.CashMovements, movement)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if report.Status == models.ShiftStatusOpen {
+		expected := report.OpeningFloat + report.CashSales + report.CashIn - report.CashOut - report.CashRefunds
+		report.ExpectedCash = &expected
+	}
+	return nil
+}
+
+// lockShift memastikan shift masih open. forUpdate dipakai saat menutup
+// shift; checkout, refund dan kas masuk/keluar memakai FOR SHARE agar bisa
+// berjalan bersamaan tetapi tertahan selama shift sedang ditutup.
+func lockShift(tx *sql.Tx, id string, forUpdate bool) error {
+	lock := "FOR SHARE"
+	if forUpdate {
+		lock = "FOR UPDATE"
+	}
+
+	var status string
+	err := tx.QueryRow("SELECT status FROM shifts WHERE id = $1 "+lock, id).Scan(&status)
+	if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+		return ErrShiftNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != models.ShiftStatusOpen {
+		return ErrShiftClosed
+	}
+	return nil
+}
+
+// openShiftForCheckout mengunci shift open milik kasir. nil berarti kasir
+// tidak sedang membuka shift sehingga transaksi tidak terikat ke shift.
+func openShiftForCheckout(tx *sql.Tx, cashierID string) (*string, error) {
+	var id string
+	err := tx.QueryRow("SELECT id FROM shifts WHERE cashier_id = $1 AND status = $2 FOR SHARE", cashierID, models.ShiftStatusOpen).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+func (r *ShiftRepository) AddCashMovement(id string, req models.CashMovementRequest, actor *models.AuthUser) (*models.CashMovement, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockShift(tx, id, false); err != nil {
+		return nil, err
+	}
+
+	movement := models.CashMovement{
+		ShiftID: id,
+		Type:    req.Type,
+		Amount:  req.Amount,
+		Reason:  req.Reason,
+	}
+	if actor != nil {
+		movement.CreatedBy = &actor.ID
+	}
+
+	err = tx.QueryRow("INSERT INTO shift_cash_movements (shift_id, type, amount, reason, created_by) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at::timestamptz", id, movement.Type, movement.Amount, movement.Reason, movement.CreatedBy).Scan(&movement.ID, &movement.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &movement, nil
+}
+
+// CloseShift menutup shift dan menyimpan expected cash dan counted cash.
+// Baris shift dikunci FOR UPDATE sehingga checkout dan refund yang sedang
+// berjalan selesai lebih dulu dan ikut terhitung.
+func (r *ShiftRepository) CloseShift(id string, req models.CloseShiftRequest, actor *models.AuthUser) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockShift(tx, id, true); err != nil {
+		return err
+	}
+
+	var report models.ShiftReport
+	if err := scanShift(tx.QueryRow(shiftSelect+" WHERE s.id = $1", id), &report.Shift); err != nil {
+		return err
+	}
+	if err := shiftSummary(tx, &report); err != nil {
+		return err
+	}
+
+	var closedBy *string
+	if actor != nil {
+		closedBy = &actor.ID
+	}
+	_, err = tx.Exec("UPDATE shifts SET status = $1, closed_by = $2, closed_at = NOW(), expected_cash = $3, counted_cash = $4, note = COALESCE($5, note) WHERE id = $6", models.ShiftStatusClosed, closedBy, *report.ExpectedCash, *req.CountedCash, req.Note, id)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
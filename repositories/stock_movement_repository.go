@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"labkoding.my.id/kasir-api/models"
+)
+
+type StockMovementRepository struct {
+	db *sql.DB
+}
+
+func NewStockMovementRepository(db *sql.DB) *StockMovementRepository {
+	return &StockMovementRepository{
+		db: db,
+	}
+}
+
+// applyStockMovement mengubah products.stock sebesar movement.Quantity dan
+// mencatatnya ke ledger di dalam transaksi database yang sama. StockAfter,
+// ID dan CreatedAt diisi dari hasil query. Jika pergerakan ini membuat stok
+// turun dari atas reorder point sampai ke reorder point atau di bawahnya,
+// produk tersebut dikembalikan sebagai crossed; selain itu crossed nil.
+func applyStockMovement(tx *sql.Tx, movement *models.StockMovement, actor *models.AuthUser) (crossed *models.LowStockProduct, err error) {
+	var reorderPoint int
+	err = tx.QueryRow("UPDATE products SET stock = stock + $1 WHERE id = $2 RETURNING stock, reorder_point", movement.Quantity, movement.ProductID).Scan(&movement.StockAfter, &reorderPoint)
+	if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+		return nil, fmt.Errorf("%w: %s", ErrProductNotFound, movement.ProductID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if movement.StockAfter < 0 {
+		return nil, ErrNegativeStock
+	}
+
+	if actor != nil {
+		movement.ActorID = &actor.ID
+	}
+
+	err = tx.QueryRow("INSERT INTO stock_movements (product_id, type, quantity, stock_after, reference_id, reason, actor_id) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at::timestamptz",
+		movement.ProductID, movement.Type, movement.Quantity, movement.StockAfter, movement.ReferenceID, movement.Reason, movement.ActorID,
+	).Scan(&movement.ID, &movement.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// baris produk sudah terkunci oleh UPDATE di atas, sehingga dua pergerakan
+	// yang bersamaan tidak bisa sama-sama melewati reorder point
+	stockBefore := movement.StockAfter - movement.Quantity
+	if reorderPoint <= 0 || movement.StockAfter > reorderPoint || stockBefore <= reorderPoint {
+		return nil, nil
+	}
+	var product models.LowStockProduct
+	err = tx.QueryRow(lowStockSelect+" WHERE p.id = $1", movement.ProductID).Scan(&product.ProductID, &product.ProductName, &product.CategoryID, &product.CategoryName, &product.Stock, &product.ReorderPoint, &product.ReorderQty)
+	if err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// CreateAdjustment mencatat koreksi stok manual (adjustment atau waste)
+func (r *StockMovementRepository) CreateAdjustment(movement *models.StockMovement, actor *models.AuthUser) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := applyStockMovement(tx, movement, actor); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *StockMovementRepository) GetStockMovements(filter models.StockMovementFilter) ([]models.StockMovement, int, error) {
+	if _, err := getProduct(r.db, filter.ProductID, false); err != nil {
+		return nil, 0, err
+	}
+
+	where := " WHERE product_id = $1"
+	args := []interface{}{filter.ProductID}
+	if filter.Type != "" {
+		args = append(args, filter.Type)
+		where += fmt.Sprintf(" AND type = $%d", len(args))
+	}
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM stock_movements"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT id, product_id, type, quantity, stock_after, reference_id, reason, actor_id, created_at::timestamptz FROM stock_movements" + where
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	movements := make([]models.StockMovement, 0)
+	for rows.Next() {
+		var movement models.StockMovement
+		if err := rows.Scan(&movement.ID, &movement.ProductID, &movement.Type, &movement.Quantity, &movement.StockAfter, &movement.ReferenceID, &movement.Reason, &movement.ActorID, &movement.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		movements = append(movements, movement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return movements, total, nil
+}
@@ -0,0 +1,277 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"labkoding.my.id/kasir-api/models"
+)
+
+const stockTakeSelect = "SELECT st.id, st.status, st.note, st.opened_by, st.opened_at::timestamptz, st.closed_by, st.closed_at::timestamptz, (SELECT COUNT(*) FROM stock_take_counts c WHERE c.stock_take_id = st.id) FROM stock_takes st"
+
+type StockTakeRepository struct {
+	db *sql.DB
+}
+
+func NewStockTakeRepository(db *sql.DB) *StockTakeRepository {
+	return &StockTakeRepository{
+		db: db,
+	}
+}
+
+func scanStockTake(scanner interface{ Scan(...interface{}) error }, stockTake *models.StockTake) error {
+	return scanner.Scan(&stockTake.ID, &stockTake.Status, &stockTake.Note, &stockTake.OpenedBy, &stockTake.OpenedAt, &stockTake.ClosedBy, &stockTake.ClosedAt, &stockTake.ItemCount)
+}
+
+func (r *StockTakeRepository) CreateStockTake(req models.StockTakeRequest, actor *models.AuthUser) (*models.StockTake, error) {
+	stockTake := models.StockTake{Status: models.StockTakeStatusOpen, Note: req.Note}
+	if actor != nil {
+		stockTake.OpenedBy = &actor.ID
+	}
+
+	err := r.db.QueryRow("INSERT INTO stock_takes (note, opened_by) VALUES ($1, $2) RETURNING id, opened_at::timestamptz", stockTake.Note, stockTake.OpenedBy).Scan(&stockTake.ID, &stockTake.OpenedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stockTake, nil
+}
+
+func (r *StockTakeRepository) GetAllStockTakes(status string) ([]models.StockTake, error) {
+	query := stockTakeSelect
+	args := []interface{}{}
+	if status != "" {
+		args = append(args, status)
+		query += " WHERE st.status = $1"
+	}
+	query += " ORDER BY st.opened_at DESC"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stockTakes := make([]models.StockTake, 0)
+	for rows.Next() {
+		var stockTake models.StockTake
+		if err := scanStockTake(rows, &stockTake); err != nil {
+			return nil, err
+		}
+		stockTakes = append(stockTakes, stockTake)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return stockTakes, nil
+}
+
+// GetStockTakeReport memuat sesi beserta selisih setiap produk yang sudah dihitung
+func (r *StockTakeRepository) GetStockTakeReport(id string) (*models.StockTakeReport, error) {
+	var report models.StockTakeReport
+	if err := scanStockTake(r.db.QueryRow(stockTakeSelect+" WHERE st.id = $1", id), &report.StockTake); err != nil {
+		if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+			return nil, ErrStockTakeNotFound
+		}
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`
+		SELECT c.product_id, p.name, c.system_stock, c.counted_qty, p.price, c.counted_by, c.updated_at::timestamptz
+		FROM stock_take_counts c
+		JOIN products p ON p.id = c.product_id
+		WHERE c.stock_take_id = $1
+		ORDER BY p.name, c.product_id`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report.Lines = make([]models.StockTakeLine, 0)
+	for rows.Next() {
+		var line models.StockTakeLine
+		if err := rows.Scan(&line.ProductID, &line.ProductName, &line.SystemStock, &line.CountedQuantity, &line.Price, &line.CountedBy, &line.UpdatedAt); err != nil {
+			return nil, err
+		}
+		line.Variance = line.CountedQuantity - line.SystemStock
+		line.VarianceValue = line.Variance * line.Price
+
+		if line.VarianceValue > 0 {
+			report.SurplusValue += line.VarianceValue
+		} else {
+			report.ShortageValue -= line.VarianceValue
+		}
+		report.VarianceValue += line.VarianceValue
+		report.Lines = append(report.Lines, line)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &report, nil
+}
+
+// lockStockTake memastikan sesi masih open. forUpdate dipakai saat menutup
+// sesi; batch hitungan memakai FOR SHARE agar beberapa perangkat bisa
+// mengirim bersamaan tetapi tetap tertahan selama sesi sedang difinalisasi.
+func lockStockTake(tx *sql.Tx, id string, forUpdate bool) error {
+	lock := "FOR SHARE"
+	if forUpdate {
+		lock = "FOR UPDATE"
+	}
+
+	var status string
+	err := tx.QueryRow("SELECT status FROM stock_takes WHERE id = $1 "+lock, id).Scan(&status)
+	if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+		return ErrStockTakeNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != models.StockTakeStatusOpen {
+		return ErrStockTakeClosed
+	}
+	return nil
+}
+
+// SubmitCounts menyimpan satu batch hasil hitung. Stok sistem disalin saat
+// produk pertama kali dihitung dan tidak berubah pada batch berikutnya.
+func (r *StockTakeRepository) SubmitCounts(id string, req models.StockTakeCountRequest, actor *models.AuthUser) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockStockTake(tx, id, false); err != nil {
+		return err
+	}
+
+	var countedBy *string
+	if actor != nil {
+		countedBy = &actor.ID
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO stock_take_counts (stock_take_id, product_id, system_stock, counted_qty, counted_by)
+		SELECT $1, p.id, p.stock, $3, $4 FROM products p WHERE p.id = $2
+		ON CONFLICT (stock_take_id, product_id) DO UPDATE SET
+			counted_qty = CASE WHEN $5 = 'add' THEN stock_take_counts.counted_qty + EXCLUDED.counted_qty ELSE EXCLUDED.counted_qty END,
+			counted_by = EXCLUDED.counted_by,
+			updated_at = NOW()`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, item := range req.Items {
+		result, err := stmt.Exec(id, item.ProductID, item.CountedQuantity, countedBy, req.Mode)
+		if err != nil {
+			return err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return fmt.Errorf("%w: %s", ErrProductNotFound, item.ProductID)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// FinalizeStockTake menutup sesi dan mencatat selisih setiap produk sebagai
+// pergerakan stok bertipe stock_take
+func (r *StockTakeRepository) FinalizeStockTake(id string, actor *models.AuthUser) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockStockTake(tx, id, true); err != nil {
+		return err
+	}
+
+	// Stok akhir adalah hasil hitung dikurangi penjualan sejak produk dihitung
+	// (stok terkini + selisih). Jika penjualan setelah hitung melebihi hasil
+	// hitung, stok dibatasi menjadi 0 agar finalisasi tidak gagal NEGATIVE_STOCK.
+	rows, err := tx.Query(`
+		SELECT c.product_id, GREATEST(c.counted_qty - c.system_stock, -p.stock)
+		FROM stock_take_counts c
+		JOIN products p ON p.id = c.product_id
+		WHERE c.stock_take_id = $1 AND c.counted_qty <> c.system_stock
+		ORDER BY c.product_id
+		FOR UPDATE OF p`, id)
+	if err != nil {
+		return err
+	}
+	type variance struct {
+		productID string
+		quantity  int
+	}
+	variances := make([]variance, 0)
+	for rows.Next() {
+		var v variance
+		if err := rows.Scan(&v.productID, &v.quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		if v.quantity == 0 {
+			continue
+		}
+		variances = append(variances, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	reason := "koreksi stock take"
+	for _, v := range variances {
+		_, err := applyStockMovement(tx, &models.StockMovement{
+			ProductID:   v.productID,
+			Type:        models.StockMovementStockTake,
+			Quantity:    v.quantity,
+			ReferenceID: &id,
+			Reason:      &reason,
+		}, actor)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := closeStockTake(tx, id, models.StockTakeStatusFinalized, actor); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *StockTakeRepository) CancelStockTake(id string, actor *models.AuthUser) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := lockStockTake(tx, id, true); err != nil {
+		return err
+	}
+	if err := closeStockTake(tx, id, models.StockTakeStatusCancelled, actor); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func closeStockTake(tx *sql.Tx, id, status string, actor *models.AuthUser) error {
+	var closedBy *string
+	if actor != nil {
+		closedBy = &actor.ID
+	}
+	_, err := tx.Exec("UPDATE stock_takes SET status = $1, closed_by = $2, closed_at = NOW() WHERE id = $3", status, closedBy, id)
+	return err
+}
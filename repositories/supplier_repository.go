@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"labkoding.my.id/kasir-api/models"
+)
+
+const supplierColumns = "id, name, contact_name, phone, email, address, active, created_at::timestamptz"
+
+type SupplierRepository struct {
+	db *sql.DB
+}
+
+func NewSupplierRepository(db *sql.DB) *SupplierRepository {
+	return &SupplierRepository{
+		db: db,
+	}
+}
+
+func scanSupplier(scanner interface{ Scan(...interface{}) error }, supplier *models.Supplier) error {
+	return scanner.Scan(&supplier.ID, &supplier.Name, &supplier.ContactName, &supplier.Phone, &supplier.Email, &supplier.Address, &supplier.Active, &supplier.CreatedAt)
+}
+
+func (r *SupplierRepository) GetAllSuppliers(name string) ([]models.Supplier, error) {
+	query := "SELECT " + supplierColumns + " FROM suppliers"
+	args := []interface{}{}
+	if name != "" {
+		args = append(args, "%"+name+"%")
+		query += " WHERE name ILIKE $1"
+	}
+	query += " ORDER BY name"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := make([]models.Supplier, 0)
+	for rows.Next() {
+		var supplier models.Supplier
+		if err := scanSupplier(rows, &supplier); err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, supplier)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return suppliers, nil
+}
+
+func (r *SupplierRepository) GetSupplierByID(id string) (*models.Supplier, error) {
+	var supplier models.Supplier
+
+	row := r.db.QueryRow("SELECT "+supplierColumns+" FROM suppliers WHERE id = $1", id)
+	if err := scanSupplier(row, &supplier); err != nil {
+		if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+			return nil, ErrSupplierNotFound
+		}
+		return nil, err
+	}
+
+	return &supplier, nil
+}
+
+func (r *SupplierRepository) CreateSupplier(supplier *models.Supplier) error {
+	return r.db.QueryRow("INSERT INTO suppliers (name, contact_name, phone, email, address, active) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at::timestamptz",
+		supplier.Name, supplier.ContactName, supplier.Phone, supplier.Email, supplier.Address, supplier.Active,
+	).Scan(&supplier.ID, &supplier.CreatedAt)
+}
+
+func (r *SupplierRepository) UpdateSupplier(supplier *models.Supplier) error {
+	err := r.db.QueryRow("UPDATE suppliers SET name = $1, contact_name = $2, phone = $3, email = $4, address = $5, active = $6 WHERE id = $7 RETURNING created_at::timestamptz",
+		supplier.Name, supplier.ContactName, supplier.Phone, supplier.Email, supplier.Address, supplier.Active, supplier.ID,
+	).Scan(&supplier.CreatedAt)
+	if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+		return ErrSupplierNotFound
+	}
+	return err
+}
+
+func (r *SupplierRepository) DeleteSupplier(id string) error {
+	result, err := r.db.Exec("DELETE FROM suppliers WHERE id = $1", id)
+	if isForeignKeyViolation(err) {
+		return ErrSupplierInUse
+	}
+	if isInvalidTextRepresentation(err) {
+		return ErrSupplierNotFound
+	}
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrSupplierNotFound
+	}
+	return nil
+}
@@ -2,76 +2,247 @@ package repositories
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"labkoding.my.id/kasir-api/models"
 )
 
 type TransactionRepository struct {
-	db *sql.DB
+	db        *sql.DB
+	taxConfig models.TaxConfig
 }
 
-func NewTransactionRepository(db *sql.DB) *TransactionRepository {
+func NewTransactionRepository(db *sql.DB, taxConfig models.TaxConfig) *TransactionRepository {
 	return &TransactionRepository{
-		db: db,
+		db:        db,
+		taxConfig: taxConfig,
 	}
 }
 
-func (r *TransactionRepository) CreateTransaction(items []models.CheckoutItem) (*models.Transaction, error) {
+// pricedLine adalah satu baris keranjang yang harganya sudah dikunci
+type pricedLine struct {
+	productID  string
+	categoryID string
+	taxExempt  bool
+	unitPrice  int
+	unitCost   int
+	quantity   int
+
+	discount      int
+	promotionID   *string
+	serviceCharge int
+	tax           int
+	taxInclusive  bool
+}
+
+func (l pricedLine) gross() int {
+	return l.unitPrice * l.quantity
+}
+
+// net adalah nilai baris setelah diskon, sebelum service charge dan pajak
+func (l pricedLine) net() int {
+	return l.gross() - l.discount
+}
+
+// total adalah nilai yang dibayar pelanggan untuk baris ini
+func (l pricedLine) total() int {
+	total := l.net() + l.serviceCharge
+	if !l.taxInclusive {
+		total += l.tax
+	}
+	return total
+}
+
+// applyTax menghitung service charge dan PPN per baris setelah diskon.
+// PPN dikenakan atas nilai bersih ditambah service charge. Untuk harga
+// inclusive, PPN adalah bagian dari harga sehingga total baris tidak bertambah.
+func applyTax(lines []pricedLine, config models.TaxConfig) {
+	for i := range lines {
+		line := &lines[i]
+		line.serviceCharge = int(math.Round(float64(line.net()) * config.ServiceChargeRate / 100))
+		line.taxInclusive = config.Inclusive
+
+		if line.taxExempt || config.Rate <= 0 {
+			continue
+		}
+		base := float64(line.net() + line.serviceCharge)
+		if config.Inclusive {
+			line.tax = int(math.Round(base * config.Rate / (100 + config.Rate)))
+		} else {
+			line.tax = int(math.Round(base * config.Rate / 100))
+		}
+	}
+}
+
+// CreateTransaction membuat transaksi dari request yang sudah divalidasi.
+// Jika req.IdempotencyKey diisi, key disimpan per kasir dalam transaksi database
+// yang sama sehingga checkout yang diulang dengan key yang sama tidak membuat
+// transaksi baru. lowStock berisi produk yang stoknya turun melewati reorder
+// point karena transaksi ini; dihitung saat baris produk masih terkunci sehingga
+// checkout yang bersamaan tidak melaporkan produk yang sama dua kali.
+func (r *TransactionRepository) CreateTransaction(req models.CheckoutRequest, requestHash string) (transaction *models.Transaction, lowStock []models.LowStockProduct, err error) {
+	items, paymentInputs := req.Items, req.Payments
+
 	tx, err := r.db.Begin()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer tx.Rollback()
 
-	totalAmount := 0
-	details := make([]models.TransactionDetail, 0)
+	var cashierID *string
+	if req.Cashier != nil {
+		cashierID = &req.Cashier.ID
+	}
+
+	if req.IdempotencyKey != "" {
+		// insert akan menunggu jika request lain dengan key dan kasir yang sama
+		// sedang berjalan
+		result, err := tx.Exec("insert into idempotency_keys (key, cashier_id, request_hash) values ($1, $2, $3) on conflict do nothing", req.IdempotencyKey, cashierID, requestHash)
+		if err != nil {
+			return nil, nil, err
+		}
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return nil, nil, err
+		}
+		if inserted == 0 {
+			return nil, nil, ErrIdempotencyKeyExists
+		}
+	}
+
+	// Hitung total quantity per produk lalu kunci baris produk dengan urutan id
+	// yang konsisten supaya dua checkout yang bersamaan tidak saling deadlock.
+	requested := make(map[string]int)
+	productIDs := make([]string, 0)
+	for _, item := range items {
+		if _, ok := requested[item.ProductID]; !ok {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		requested[item.ProductID] += item.Quantity
+	}
+	sort.Strings(productIDs)
 
-	stmtProd, err := tx.Prepare("select name, price, stock from products where id = $1")
+	stmtProd, err := tx.Prepare("select p.name, p.price, p.cost_price, p.stock, p.category_id, COALESCE(c.tax_exempt, false) from products p left join categories c on c.id = p.category_id where p.id = $1 for update of p")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer stmtProd.Close()
 
-	for _, item := range items {
-		var productPrice, stock int
+	type lockedProduct struct {
+		name       string
+		price      int
+		cost       int
+		categoryID string
+		taxExempt  bool
+	}
+	products := make(map[string]lockedProduct, len(productIDs))
+	shortages := make([]models.StockShortage, 0)
+
+	for _, productID := range productIDs {
+		var productPrice, productCost, stock int
 		var productName string
+		var categoryID sql.NullString
+		var taxExempt bool
 
-		err := stmtProd.QueryRow(item.ProductID).Scan(&productName, &productPrice, &stock)
+		err := stmtProd.QueryRow(productID).Scan(&productName, &productPrice, &productCost, &stock, &categoryID, &taxExempt)
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("product with id %s not found", item.ProductID)
+			// product_id sudah dicek di service; ini hanya terjadi jika produk
+			// dihapus di antara pengecekan dan checkout
+			return nil, nil, fmt.Errorf("%w: %s", ErrInvalidProduct, productID)
 		}
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
-		subTotal := productPrice * item.Quantity
-		totalAmount += subTotal
-
-		_, err = tx.Exec("update products set stock = stock - $1 where id = $2", item.Quantity, item.ProductID)
-		if err != nil {
-			return nil, err
+		if stock < requested[productID] {
+			shortages = append(shortages, models.StockShortage{
+				ProductID:   productID,
+				ProductName: productName,
+				Requested:   requested[productID],
+				Available:   stock,
+			})
 		}
+		products[productID] = lockedProduct{name: productName, price: productPrice, cost: productCost, categoryID: categoryID.String, taxExempt: taxExempt}
+	}
+
+	if len(shortages) > 0 {
+		return nil, nil, ErrInsufficientStock.WithDetails(shortages)
+	}
+
+	lines := make([]pricedLine, 0, len(items))
+	for _, item := range items {
+		product := products[item.ProductID]
+		lines = append(lines, pricedLine{
+			productID:  item.ProductID,
+			categoryID: product.categoryID,
+			taxExempt:  product.taxExempt,
+			unitPrice:  product.price,
+			unitCost:   product.cost,
+			quantity:   item.Quantity,
+		})
+	}
+
+	promotions, err := activePromotions(tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	cartPromotionID := applyPromotions(lines, promotions)
+	applyTax(lines, r.taxConfig)
+
+	grossAmount, discountAmount, serviceChargeAmount, taxAmount, totalAmount := 0, 0, 0, 0, 0
+	details := make([]models.TransactionDetail, 0, len(lines))
+	for _, line := range lines {
+		grossAmount += line.gross()
+		discountAmount += line.discount
+		serviceChargeAmount += line.serviceCharge
+		taxAmount += line.tax
+		totalAmount += line.total()
 
 		details = append(details, models.TransactionDetail{
-			ProductID:   item.ProductID,
-			ProductName: productName,
-			Quantity:    item.Quantity,
-			Subtotal:    subTotal,
+			ProductID:           line.productID,
+			ProductName:         products[line.productID].name,
+			Quantity:            line.quantity,
+			GrossAmount:         line.gross(),
+			DiscountAmount:      line.discount,
+			PromotionID:         line.promotionID,
+			ServiceChargeAmount: line.serviceCharge,
+			TaxAmount:           line.tax,
+			Subtotal:            line.total(),
+			CostPrice:           line.unitCost,
 		})
 	}
 
+	payments, paidAmount, changeAmount, err := allocatePayments(totalAmount, paymentInputs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var shiftID *string
+	if req.Cashier != nil {
+		// transaksi diikat ke shift kasir yang sedang open; kunci FOR SHARE
+		// menahan penutupan shift sampai checkout selesai
+		shiftID, err = openShiftForCheckout(tx, req.Cashier.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	var transactionID string
 	var createdAt time.Time
-	err = tx.QueryRow("insert into transactions (total_amount) values ($1) returning id, created_at", totalAmount).Scan(&transactionID, &createdAt)
+	err = tx.QueryRow("insert into transactions (gross_amount, discount_amount, service_charge_amount, tax_amount, tax_rate, tax_inclusive, total_amount, promotion_id, cashier_id, shift_id, paid_amount, change_amount) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) returning id, created_at::timestamptz", grossAmount, discountAmount, serviceChargeAmount, taxAmount, r.taxConfig.Rate, r.taxConfig.Inclusive, totalAmount, cartPromotionID, cashierID, shiftID, paidAmount, changeAmount).Scan(&transactionID, &createdAt)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	stmt, err := tx.Prepare("insert into transaction_details (transaction_id, product_id, quantity, subtotal) values ($1, $2, $3, $4) returning id")
+	stmt, err := tx.Prepare("insert into transaction_details (transaction_id, line_no, product_id, quantity, gross_amount, discount_amount, promotion_id, service_charge_amount, tax_amount, subtotal, cost_price) values ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) returning id")
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer stmt.Close()
 
@@ -79,21 +250,656 @@ func (r *TransactionRepository) CreateTransaction(items []models.CheckoutItem) (
 		details[i].TransactionID = transactionID
 
 		var detailID string
-		err = stmt.QueryRow(transactionID, details[i].ProductID, details[i].Quantity, details[i].Subtotal).Scan(&detailID)
+		err = stmt.QueryRow(transactionID, i+1, details[i].ProductID, details[i].Quantity, details[i].GrossAmount, details[i].DiscountAmount, details[i].PromotionID, details[i].ServiceChargeAmount, details[i].TaxAmount, details[i].Subtotal, details[i].CostPrice).Scan(&detailID)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		details[i].ID = detailID
 	}
 
+	// stok sudah dicek saat baris produk dikunci, pengurangan dicatat ke
+	// ledger setelah transaksi dibuat agar bisa merujuk id transaksi
+	lowStock = make([]models.LowStockProduct, 0)
+	for _, productID := range productIDs {
+		crossed, err := applyStockMovement(tx, &models.StockMovement{
+			ProductID:   productID,
+			Type:        models.StockMovementSale,
+			Quantity:    -requested[productID],
+			ReferenceID: &transactionID,
+		}, req.Cashier)
+		if err != nil {
+			return nil, nil, err
+		}
+		if crossed != nil {
+			lowStock = append(lowStock, *crossed)
+		}
+	}
+
+	if req.IdempotencyKey != "" {
+		_, err = tx.Exec("update idempotency_keys set transaction_id = $1 where key = $2 and cashier_id is not distinct from $3", transactionID, req.IdempotencyKey, cashierID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	stmtPayment, err := tx.Prepare("insert into transaction_payments (transaction_id, line_no, method, tendered, amount) values ($1, $2, $3, $4, $5) returning id")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer stmtPayment.Close()
+
+	for i := range payments {
+		payments[i].TransactionID = transactionID
+		err = stmtPayment.QueryRow(transactionID, i+1, payments[i].Method, payments[i].Tendered, payments[i].Amount).Scan(&payments[i].ID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	transaction = &models.Transaction{
+		ID:                  transactionID,
+		GrossAmount:         grossAmount,
+		DiscountAmount:      discountAmount,
+		ServiceChargeAmount: serviceChargeAmount,
+		TaxAmount:           taxAmount,
+		TaxRate:             r.taxConfig.Rate,
+		TaxInclusive:        r.taxConfig.Inclusive,
+		TotalAmount:         totalAmount,
+		PromotionID:         cartPromotionID,
+		CashierID:           cashierID,
+		ShiftID:             shiftID,
+		PaidAmount:          paidAmount,
+		ChangeAmount:        changeAmount,
+		Status:              models.TransactionStatusCompleted,
+		Details:             details,
+		Payments:            payments,
+		CreatedAt:           createdAt,
+	}
+	if err := insertAuditLog(tx, req.Cashier, models.AuditEntityTransaction, transactionID, models.AuditActionCreate, nil, transaction); err != nil {
+		return nil, nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+	return transaction, lowStock, nil
+}
+
+// allocatePayments memastikan pembayaran menutupi total dan menghitung kembalian.
+// Pembayaran non-tunai tidak boleh melebihi total, sehingga kembalian selalu
+// diambil dari pembayaran tunai.
+func allocatePayments(totalAmount int, inputs []models.PaymentInput) ([]models.Payment, int, int, error) {
+	nonCash, paid := 0, 0
+	for _, input := range inputs {
+		if input.Method != models.PaymentMethodCash {
+			nonCash += input.Amount
+		}
+		paid += input.Amount
+	}
+
+	if nonCash > totalAmount {
+		return nil, 0, 0, fmt.Errorf("%w: non-tunai %d, total %d", ErrNonCashOverpayment, nonCash, totalAmount)
+	}
+	if paid < totalAmount {
+		return nil, 0, 0, fmt.Errorf("%w: dibayar %d, total %d", ErrInsufficientPayment, paid, totalAmount)
+	}
+
+	change := paid - totalAmount
+	payments := make([]models.Payment, len(inputs))
+	remainingChange := change
+	for i := len(inputs) - 1; i >= 0; i-- {
+		payments[i] = models.Payment{
+			Method:   inputs[i].Method,
+			Tendered: inputs[i].Amount,
+			Amount:   inputs[i].Amount,
+		}
+		if inputs[i].Method == models.PaymentMethodCash && remainingChange > 0 {
+			deduct := min(remainingChange, inputs[i].Amount)
+			payments[i].Amount -= deduct
+			remainingChange -= deduct
+		}
+	}
+
+	return payments, paid, change, nil
+}
+
+// GetIdempotencyKey memuat key milik kasir tersebut; cashierID nil untuk
+// request tanpa user
+func (r *TransactionRepository) GetIdempotencyKey(key string, cashierID *string) (*models.IdempotencyKey, error) {
+	var idempotencyKey models.IdempotencyKey
+	var transactionID sql.NullString
+
+	err := r.db.QueryRow("SELECT key, request_hash, transaction_id FROM idempotency_keys WHERE key = $1 AND cashier_id IS NOT DISTINCT FROM $2", key, cashierID).Scan(&idempotencyKey.Key, &idempotencyKey.RequestHash, &transactionID)
+	if err == sql.ErrNoRows {
+		return nil, ErrIdempotencyKeyNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	idempotencyKey.TransactionID = transactionID.String
+
+	return &idempotencyKey, nil
+}
+
+// transactionConditions menyusun kondisi WHERE dari filter daftar transaksi
+func transactionConditions(filter models.TransactionFilter) ([]string, []interface{}) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if filter.CreatedFrom != nil {
+		args = append(args, *filter.CreatedFrom)
+		conditions = append(conditions, fmt.Sprintf("t.created_at >= $%d::timestamptz", len(args)))
+	}
+	if filter.CreatedTo != nil {
+		args = append(args, *filter.CreatedTo)
+		conditions = append(conditions, fmt.Sprintf("t.created_at < $%d::timestamptz", len(args)))
+	}
+	if filter.ProductID != "" {
+		args = append(args, filter.ProductID)
+		conditions = append(conditions, fmt.Sprintf("EXISTS (SELECT 1 FROM transaction_details td WHERE td.transaction_id = t.id AND td.product_id = $%d)", len(args)))
+	}
+	if filter.ShiftID != "" {
+		args = append(args, filter.ShiftID)
+		conditions = append(conditions, fmt.Sprintf("t.shift_id = $%d", len(args)))
+	}
+	if filter.MinAmount != nil {
+		args = append(args, *filter.MinAmount)
+		conditions = append(conditions, fmt.Sprintf("t.total_amount >= $%d", len(args)))
+	}
+	if filter.MaxAmount != nil {
+		args = append(args, *filter.MaxAmount)
+		conditions = append(conditions, fmt.Sprintf("t.total_amount <= $%d", len(args)))
+	}
+
+	return conditions, args
+}
+
+func (r *TransactionRepository) GetAllTransactions(filter models.TransactionFilter) ([]models.Transaction, int, error) {
+	conditions, args := transactionConditions(filter)
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := r.db.QueryRow("SELECT COUNT(*) FROM transactions t"+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := "SELECT t.id, t.gross_amount, t.discount_amount, t.service_charge_amount, t.tax_amount, t.tax_rate, t.tax_inclusive, t.total_amount, t.promotion_id, t.cashier_id, t.shift_id, t.paid_amount, t.change_amount, t.status, t.voided_at::timestamptz, t.void_reason, t.created_at::timestamptz FROM transactions t" + where
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+	query += fmt.Sprintf(" ORDER BY t.created_at DESC, t.id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	transactions := make([]models.Transaction, 0)
+	ids := make([]string, 0)
+	for rows.Next() {
+		var transaction models.Transaction
+		if err := rows.Scan(&transaction.ID, &transaction.GrossAmount, &transaction.DiscountAmount, &transaction.ServiceChargeAmount, &transaction.TaxAmount, &transaction.TaxRate, &transaction.TaxInclusive, &transaction.TotalAmount, &transaction.PromotionID, &transaction.CashierID, &transaction.ShiftID, &transaction.PaidAmount, &transaction.ChangeAmount, &transaction.Status, &transaction.VoidedAt, &transaction.VoidReason, &transaction.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		transaction.Details = make([]models.TransactionDetail, 0)
+		transaction.Payments = make([]models.Payment, 0)
+		transactions = append(transactions, transaction)
+		ids = append(ids, transaction.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if len(ids) == 0 {
+		return transactions, total, nil
+	}
+
+	details, err := r.getDetails(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	payments, err := r.getPayments(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range transactions {
+		transactions[i].Details = append(transactions[i].Details, details[transactions[i].ID]...)
+		transactions[i].Payments = append(transactions[i].Payments, payments[transactions[i].ID]...)
+	}
+
+	return transactions, total, nil
+}
+
+// ExportTransactions memanggil fn untuk setiap transaksi yang cocok dengan
+// filter tanpa pagination, baris demi baris. Detail tidak dimuat; metode
+// pembayaran dan jumlah item dirangkum langsung di query.
+func (r *TransactionRepository) ExportTransactions(filter models.TransactionFilter, fn func(models.TransactionExportRow) error) error {
+	conditions, args := transactionConditions(filter)
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := r.db.Query(`
+		SELECT t.id, t.gross_amount, t.discount_amount, t.service_charge_amount, t.tax_amount, t.tax_rate, t.tax_inclusive, t.total_amount, t.promotion_id, t.cashier_id, t.shift_id, t.paid_amount, t.change_amount, t.status, t.voided_at::timestamptz, t.void_reason, t.created_at::timestamptz,
+			COALESCE(u.username, ''),
+			COALESCE((SELECT SUM(td.quantity) FROM transaction_details td WHERE td.transaction_id = t.id), 0),
+			COALESCE((SELECT STRING_AGG(DISTINCT tp.method, ', ') FROM transaction_payments tp WHERE tp.transaction_id = t.id), '')
+		FROM transactions t
+		LEFT JOIN users u ON u.id = t.cashier_id`+where+`
+		ORDER BY t.created_at DESC, t.id`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row models.TransactionExportRow
+		transaction := &row.Transaction
+		if err := rows.Scan(&transaction.ID, &transaction.GrossAmount, &transaction.DiscountAmount, &transaction.ServiceChargeAmount, &transaction.TaxAmount, &transaction.TaxRate, &transaction.TaxInclusive, &transaction.TotalAmount, &transaction.PromotionID, &transaction.CashierID, &transaction.ShiftID, &transaction.PaidAmount, &transaction.ChangeAmount, &transaction.Status, &transaction.VoidedAt, &transaction.VoidReason, &transaction.CreatedAt, &row.CashierUsername, &row.ItemCount, &row.PaymentMethods); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (r *TransactionRepository) GetTransactionByID(id string) (*models.Transaction, error) {
+	var transaction models.Transaction
+
+	// kolom waktu bertipe TIMESTAMP di zona waktu sesi database; cast ke
+	// timestamptz supaya yang dibaca adalah waktu absolut, bukan jam dinding
+	// yang dianggap UTC
+	row := r.db.QueryRow("SELECT id, gross_amount, discount_amount, service_charge_amount, tax_amount, tax_rate, tax_inclusive, total_amount, promotion_id, cashier_id, shift_id, paid_amount, change_amount, status, voided_at::timestamptz, void_reason, created_at::timestamptz FROM transactions WHERE id = $1", id)
+	if err := row.Scan(&transaction.ID, &transaction.GrossAmount, &transaction.DiscountAmount, &transaction.ServiceChargeAmount, &transaction.TaxAmount, &transaction.TaxRate, &transaction.TaxInclusive, &transaction.TotalAmount, &transaction.PromotionID, &transaction.CashierID, &transaction.ShiftID, &transaction.PaidAmount, &transaction.ChangeAmount, &transaction.Status, &transaction.VoidedAt, &transaction.VoidReason, &transaction.CreatedAt); err != nil {
+		if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, err
+	}
+
+	details, err := r.getDetails([]string{transaction.ID})
+	if err != nil {
+		return nil, err
+	}
+	transaction.Details = make([]models.TransactionDetail, 0, len(details[transaction.ID]))
+	transaction.Details = append(transaction.Details, details[transaction.ID]...)
+
+	payments, err := r.getPayments([]string{transaction.ID})
+	if err != nil {
+		return nil, err
+	}
+	transaction.Payments = make([]models.Payment, 0, len(payments[transaction.ID]))
+	transaction.Payments = append(transaction.Payments, payments[transaction.ID]...)
+
+	refunds, err := r.getRefunds(transaction.ID)
+	if err != nil {
 		return nil, err
 	}
-	fmt.Println(createdAt)
-	return &models.Transaction{
-		ID:          transactionID,
-		TotalAmount: totalAmount,
-		Details:     details,
-		CreatedAt:   createdAt,
-	}, nil
+	transaction.Refunds = refunds
+
+	return &transaction, nil
+}
+
+// GetProductIDsByBarcodes memetakan barcode ke product_id. Barcode yang tidak
+// terdaftar tidak ada di hasil.
+func (r *TransactionRepository) GetProductIDsByBarcodes(barcodes []string) (map[string]string, error) {
+	rows, err := r.db.Query("SELECT barcode, product_id FROM product_barcodes WHERE barcode = ANY($1)", pq.Array(barcodes))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	productIDs := make(map[string]string, len(barcodes))
+	for rows.Next() {
+		var barcode, productID string
+		if err := rows.Scan(&barcode, &productID); err != nil {
+			return nil, err
+		}
+		productIDs[barcode] = productID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return productIDs, nil
+}
+
+// GetExistingProductIDs mengembalikan product_id yang terdaftar dari daftar
+// tersebut
+func (r *TransactionRepository) GetExistingProductIDs(productIDs []string) (map[string]bool, error) {
+	rows, err := r.db.Query("SELECT id FROM products WHERE id = ANY($1::uuid[])", pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool, len(productIDs))
+	for rows.Next() {
+		var productID string
+		if err := rows.Scan(&productID); err != nil {
+			return nil, err
+		}
+		existing[productID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// getPayments mengambil pembayaran untuk beberapa transaksi sekaligus, dikelompokkan per transaction_id
+func (r *TransactionRepository) getPayments(transactionIDs []string) (map[string][]models.Payment, error) {
+	rows, err := r.db.Query("SELECT id, transaction_id, method, tendered, amount FROM transaction_payments WHERE transaction_id = ANY($1::uuid[]) ORDER BY line_no, id", pq.Array(transactionIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := make(map[string][]models.Payment)
+	for rows.Next() {
+		var payment models.Payment
+		if err := rows.Scan(&payment.ID, &payment.TransactionID, &payment.Method, &payment.Tendered, &payment.Amount); err != nil {
+			return nil, err
+		}
+		payments[payment.TransactionID] = append(payments[payment.TransactionID], payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return payments, nil
+}
+
+// getDetails mengambil detail untuk beberapa transaksi sekaligus, dikelompokkan per transaction_id
+func (r *TransactionRepository) getDetails(transactionIDs []string) (map[string][]models.TransactionDetail, error) {
+	rows, err := r.db.Query("SELECT td.id, td.transaction_id, td.product_id, COALESCE(p.name, ''), td.quantity, td.gross_amount, td.discount_amount, td.promotion_id, td.service_charge_amount, td.tax_amount, td.subtotal, td.cost_price FROM transaction_details td LEFT JOIN products p ON td.product_id = p.id WHERE td.transaction_id = ANY($1::uuid[]) ORDER BY td.line_no, td.id", pq.Array(transactionIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	details := make(map[string][]models.TransactionDetail)
+	for rows.Next() {
+		var detail models.TransactionDetail
+		if err := rows.Scan(&detail.ID, &detail.TransactionID, &detail.ProductID, &detail.ProductName, &detail.Quantity, &detail.GrossAmount, &detail.DiscountAmount, &detail.PromotionID, &detail.ServiceChargeAmount, &detail.TaxAmount, &detail.Subtotal, &detail.CostPrice); err != nil {
+			return nil, err
+		}
+		details[detail.TransactionID] = append(details[detail.TransactionID], detail)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return details, nil
+}
+
+// refundableLine adalah satu detail transaksi beserta jumlah yang sudah direfund
+type refundableLine struct {
+	lineNo         int
+	productID      string
+	quantity       int
+	subtotal       int
+	refundedQty    int
+	refundedAmount int
+}
+
+// lockTransactionForRefund mengunci baris transaksi dan memuat sisa quantity
+// yang masih bisa direfund untuk setiap detailnya. Transaksi dari shift yang
+// sudah ditutup tidak boleh diubah.
+func lockTransactionForRefund(tx *sql.Tx, transactionID string) (map[string]refundableLine, error) {
+	var status string
+	var shiftID sql.NullString
+	err := tx.QueryRow("SELECT status, shift_id FROM transactions WHERE id = $1 FOR UPDATE", transactionID).Scan(&status, &shiftID)
+	if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+		return nil, ErrTransactionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status == models.TransactionStatusVoided {
+		return nil, ErrTransactionVoided
+	}
+	if shiftID.Valid {
+		if err := lockShift(tx, shiftID.String, false); err != nil {
+			if errors.Is(err, ErrShiftClosed) {
+				return nil, ErrTransactionFrozen
+			}
+			return nil, err
+		}
+	}
+
+	rows, err := tx.Query(`
+		SELECT td.id, td.line_no, td.product_id, td.quantity, td.subtotal,
+			COALESCE(SUM(rd.quantity), 0), COALESCE(SUM(rd.amount), 0)
+		FROM transaction_details td
+		LEFT JOIN refund_details rd ON rd.transaction_detail_id = td.id
+		WHERE td.transaction_id = $1
+		GROUP BY td.id, td.line_no, td.product_id, td.quantity, td.subtotal`, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make(map[string]refundableLine)
+	for rows.Next() {
+		var detailID string
+		var line refundableLine
+		if err := rows.Scan(&detailID, &line.lineNo, &line.productID, &line.quantity, &line.subtotal, &line.refundedQty, &line.refundedAmount); err != nil {
+			return nil, err
+		}
+		lines[detailID] = line
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+// insertRefund menyimpan refund beserta detailnya dan mengembalikan stok produk
+func insertRefund(tx *sql.Tx, transactionID, refundType, reason string, lines map[string]refundableLine, items []models.RefundItem, actor *models.AuthUser) (*models.Refund, error) {
+	refund := models.Refund{
+		TransactionID: transactionID,
+		Type:          refundType,
+		Reason:        reason,
+		Details:       make([]models.RefundDetail, 0, len(items)),
+	}
+
+	for _, item := range items {
+		line := lines[item.TransactionDetailID]
+
+		// baris terakhir mengambil sisa subtotal supaya tidak ada selisih pembulatan
+		amount := line.subtotal * item.Quantity / line.quantity
+		if line.refundedQty+item.Quantity == line.quantity {
+			amount = line.subtotal - line.refundedAmount
+		}
+		refund.TotalAmount += amount
+
+		refund.Details = append(refund.Details, models.RefundDetail{
+			TransactionDetailID: item.TransactionDetailID,
+			ProductID:           line.productID,
+			Quantity:            item.Quantity,
+			Amount:              amount,
+		})
+	}
+
+	err := tx.QueryRow("INSERT INTO refunds (transaction_id, type, reason, total_amount) VALUES ($1, $2, $3, $4) RETURNING id, created_at::timestamptz", transactionID, refundType, reason, refund.TotalAmount).Scan(&refund.ID, &refund.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO refund_details (refund_id, transaction_detail_id, product_id, quantity, amount) VALUES ($1, $2, $3, $4, $5) RETURNING id")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	for i := range refund.Details {
+		detail := &refund.Details[i]
+		detail.RefundID = refund.ID
+		if err := stmt.QueryRow(refund.ID, detail.TransactionDetailID, detail.ProductID, detail.Quantity, detail.Amount).Scan(&detail.ID); err != nil {
+			return nil, err
+		}
+
+		_, err := applyStockMovement(tx, &models.StockMovement{
+			ProductID:   detail.ProductID,
+			Type:        models.StockMovementRefund,
+			Quantity:    detail.Quantity,
+			ReferenceID: &refund.ID,
+			Reason:      &refund.Reason,
+		}, actor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &refund, nil
+}
+
+// VoidTransaction membatalkan seluruh transaksi: semua quantity yang belum
+// direfund dikembalikan ke stok dan transaksi ditandai voided.
+func (r *TransactionRepository) VoidTransaction(transactionID, reason string, actor *models.AuthUser) (*models.Refund, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	lines, err := lockTransactionForRefund(tx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// baris refund mengikuti urutan baris transaksi seperti saat dibaca
+	detailIDs := make([]string, 0, len(lines))
+	for detailID := range lines {
+		detailIDs = append(detailIDs, detailID)
+	}
+	sort.Slice(detailIDs, func(i, j int) bool {
+		a, b := lines[detailIDs[i]], lines[detailIDs[j]]
+		if a.lineNo != b.lineNo {
+			return a.lineNo < b.lineNo
+		}
+		return detailIDs[i] < detailIDs[j]
+	})
+
+	items := make([]models.RefundItem, 0, len(lines))
+	for _, detailID := range detailIDs {
+		remaining := lines[detailID].quantity - lines[detailID].refundedQty
+		if remaining > 0 {
+			items = append(items, models.RefundItem{TransactionDetailID: detailID, Quantity: remaining})
+		}
+	}
+	// tidak ada lagi yang bisa dikembalikan; void kosong hanya akan
+	// mengubah status tanpa refund apa pun
+	if len(items) == 0 {
+		return nil, ErrTransactionFullyRefunded
+	}
+
+	refund, err := insertRefund(tx, transactionID, models.RefundTypeVoid, reason, lines, items, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE transactions SET status = $1, voided_at = $2::timestamptz, void_reason = $3 WHERE id = $4", models.TransactionStatusVoided, refund.CreatedAt, reason, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := map[string]interface{}{"status": models.TransactionStatusCompleted}
+	after := map[string]interface{}{"status": models.TransactionStatusVoided, "void_reason": reason, "refund": refund}
+	if err := insertAuditLog(tx, actor, models.AuditEntityTransaction, transactionID, models.AuditActionVoid, before, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// RefundTransaction mengembalikan sebagian baris transaksi
+func (r *TransactionRepository) RefundTransaction(transactionID string, req models.RefundRequest, actor *models.AuthUser) (*models.Refund, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	lines, err := lockTransactionForRefund(tx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range req.Items {
+		line, ok := lines[item.TransactionDetailID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrTransactionDetailNotFound, item.TransactionDetailID)
+		}
+		if remaining := line.quantity - line.refundedQty; item.Quantity > remaining {
+			return nil, fmt.Errorf("%w: detail %s hanya tersisa %d", ErrRefundExceedsQuantity, item.TransactionDetailID, remaining)
+		}
+	}
+
+	refund, err := insertRefund(tx, transactionID, models.RefundTypeRefund, req.Reason, lines, req.Items, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := insertAuditLog(tx, actor, models.AuditEntityTransaction, transactionID, models.AuditActionRefund, nil, refund); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+func (r *TransactionRepository) getRefunds(transactionID string) ([]models.Refund, error) {
+	rows, err := r.db.Query("SELECT rf.id, rf.transaction_id, rf.type, rf.reason, rf.total_amount, rf.created_at::timestamptz, rd.id, rd.transaction_detail_id, rd.product_id, rd.quantity, rd.amount FROM refunds rf LEFT JOIN refund_details rd ON rd.refund_id = rf.id LEFT JOIN transaction_details td ON td.id = rd.transaction_detail_id WHERE rf.transaction_id = $1 ORDER BY rf.created_at, rf.id, td.line_no, rd.id", transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	refunds := make([]models.Refund, 0)
+	for rows.Next() {
+		var refund models.Refund
+		var detailID, detailTransactionDetailID, detailProductID sql.NullString
+		var detailQuantity, detailAmount sql.NullInt64
+		if err := rows.Scan(&refund.ID, &refund.TransactionID, &refund.Type, &refund.Reason, &refund.TotalAmount, &refund.CreatedAt, &detailID, &detailTransactionDetailID, &detailProductID, &detailQuantity, &detailAmount); err != nil {
+			return nil, err
+		}
+
+		if len(refunds) == 0 || refunds[len(refunds)-1].ID != refund.ID {
+			refund.Details = make([]models.RefundDetail, 0)
+			refunds = append(refunds, refund)
+		}
+		if detailID.Valid {
+			last := &refunds[len(refunds)-1]
+			last.Details = append(last.Details, models.RefundDetail{
+				ID:                  detailID.String,
+				RefundID:            refund.ID,
+				TransactionDetailID: detailTransactionDetailID.String,
+				ProductID:           detailProductID.String,
+				Quantity:            int(detailQuantity.Int64),
+				Amount:              int(detailAmount.Int64),
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return refunds, nil
 }
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"database/sql"
+
+	"labkoding.my.id/kasir-api/models"
+)
+
+const userColumns = "id, username, name, role, active, password_hash, created_at::timestamptz"
+
+type UserRepository struct {
+	db *sql.DB
+}
+
+func NewUserRepository(db *sql.DB) *UserRepository {
+	return &UserRepository{
+		db: db,
+	}
+}
+
+func scanUser(scanner interface{ Scan(...interface{}) error }, user *models.User) error {
+	return scanner.Scan(&user.ID, &user.Username, &user.Name, &user.Role, &user.Active, &user.PasswordHash, &user.CreatedAt)
+}
+
+func (r *UserRepository) GetAllUsers() ([]models.User, error) {
+	rows, err := r.db.Query("SELECT " + userColumns + " FROM users ORDER BY username")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		var user models.User
+		if err := scanUser(rows, &user); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *UserRepository) GetUserByID(id string) (*models.User, error) {
+	var user models.User
+
+	row := r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = $1", id)
+	if err := scanUser(row, &user); err != nil {
+		if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) GetUserByUsername(username string) (*models.User, error) {
+	var user models.User
+
+	row := r.db.QueryRow("SELECT "+userColumns+" FROM users WHERE username = $1", username)
+	if err := scanUser(row, &user); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) CountUsers() (int, error) {
+	var total int
+	err := r.db.QueryRow("SELECT COUNT(*) FROM users").Scan(&total)
+	return total, err
+}
+
+func (r *UserRepository) CreateUser(user *models.User) error {
+	err := r.db.QueryRow("INSERT INTO users (username, name, password_hash, role, active) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at::timestamptz",
+		user.Username, user.Name, user.PasswordHash, user.Role, user.Active,
+	).Scan(&user.ID, &user.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrUsernameExists
+	}
+	return err
+}
+
+func (r *UserRepository) UpdateUser(user *models.User) error {
+	err := r.db.QueryRow("UPDATE users SET username = $1, name = $2, password_hash = $3, role = $4, active = $5 WHERE id = $6 RETURNING created_at::timestamptz",
+		user.Username, user.Name, user.PasswordHash, user.Role, user.Active, user.ID,
+	).Scan(&user.CreatedAt)
+	if err == sql.ErrNoRows || isInvalidTextRepresentation(err) {
+		return ErrUserNotFound
+	}
+	if isUniqueViolation(err) {
+		return ErrUsernameExists
+	}
+	return err
+}
+
+func (r *UserRepository) DeleteUser(id string) error {
+	result, err := r.db.Exec("DELETE FROM users WHERE id = $1", id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return ErrUserInUse
+		}
+		if isInvalidTextRepresentation(err) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
@@ -2,74 +2,251 @@ package router
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"labkoding.my.id/kasir-api/handler"
+	"labkoding.my.id/kasir-api/models"
 	"labkoding.my.id/kasir-api/repositories"
 	"labkoding.my.id/kasir-api/services"
 )
 
+// Config berisi pengaturan aplikasi yang dibutuhkan oleh repository dan service
+type Config struct {
+	Tax  models.TaxConfig
+	Auth models.AuthConfig
+	// CostMethod adalah metode perhitungan HPP, lihat models.CostMethods
+	CostMethod string
+	// LowStockNotifier menerima event stok menipis setelah checkout
+	LowStockNotifier services.LowStockNotifier
+	// Location adalah zona waktu toko untuk laporan dan filter tanggal
+	Location *time.Location
+	// Receipt berisi identitas toko dan lebar kertas default untuk struk
+	Receipt models.ReceiptConfig
+}
+
+var (
+	managerOnly = handler.RequireRole(models.RoleOwner, models.RoleManager)
+	ownerOnly   = handler.RequireRole(models.RoleOwner)
+)
+
 type Router struct {
 	db     *sql.DB
 	router chi.Router
+	config Config
+	auth   *handler.AuthHandler
+}
+
+func NewRouter(db *sql.DB, r chi.Router, config Config) *Router {
+	authService := services.NewAuthService(repositories.NewUserRepository(db), config.Auth)
+	return &Router{db: db, router: r, config: config, auth: handler.NewAuthHandler(authService)}
 }
 
-func NewRouter(db *sql.DB, r chi.Router) *Router {
-	return &Router{db: db, router: r}
+func (rt *Router) RegisterAuthRoutes() {
+	rt.router.Route("/auth", func(r chi.Router) {
+		r.Post("/login", rt.auth.Login)
+		r.With(rt.auth.Authenticate).Get("/me", rt.auth.Me)
+	})
+}
+
+func (rt *Router) RegisterUserRoutes() {
+	userRepo := repositories.NewUserRepository(rt.db)
+	userService := services.NewUserService(userRepo)
+	userHandler := handler.NewUserHandler(userService)
+
+	rt.router.Route("/users", func(r chi.Router) {
+		r.Use(rt.auth.Authenticate, ownerOnly)
+		r.Get("/", userHandler.GetAllUsers)
+		r.Post("/", userHandler.CreateUser)
+		r.Get("/{id}", userHandler.GetUserByID)
+		r.Put("/{id}", userHandler.UpdateUser)
+		r.Delete("/{id}", userHandler.DeleteUser)
+	})
 }
 
 func (rt *Router) RegisterCategoryRoutes() {
 	categoryRepo := repositories.NewCategoryRepository(rt.db)
 	categoryService := services.NewCategoryService(categoryRepo)
-	categoryHandler := handler.NewCategoryHandler(categoryService)
+	productService := services.NewProductService(repositories.NewProductRepository(rt.db), rt.config.Location)
+	categoryHandler := handler.NewCategoryHandler(categoryService, productService)
 
 	rt.router.Route("/categories", func(r chi.Router) {
+		r.Use(rt.auth.Authenticate)
 		r.Get("/", categoryHandler.GetAllCategory)
-		r.Post("/", categoryHandler.CreateCategory)
+		r.With(managerOnly).Post("/", categoryHandler.CreateCategory)
 		r.Get("/{id}", categoryHandler.GetCategoryByID)
-		r.Put("/{id}", categoryHandler.UpdateCategory)
-		r.Delete("/{id}", categoryHandler.DeleteCategory)
+		r.Get("/{id}/products", categoryHandler.GetCategoryProducts)
+		r.With(managerOnly).Put("/{id}", categoryHandler.UpdateCategory)
+		r.With(managerOnly).Delete("/{id}", categoryHandler.DeleteCategory)
 	})
 }
 
 func (rt *Router) RegisterProductRoutes() {
 	productRepo := repositories.NewProductRepository(rt.db)
-	productService := services.NewProductService(productRepo)
+	productService := services.NewProductService(productRepo, rt.config.Location)
 	productHandler := handler.NewProductHandler(productService)
+	stockService := services.NewStockService(repositories.NewStockMovementRepository(rt.db))
+	stockHandler := handler.NewStockHandler(stockService)
 
 	rt.router.Route("/products", func(r chi.Router) {
+		r.Use(rt.auth.Authenticate)
 		r.Get("/", productHandler.GetAllProduct)
-		r.Post("/", productHandler.CreateProduct)
+		r.With(managerOnly).Post("/", productHandler.CreateProduct)
+		r.Get("/lookup", productHandler.LookupProduct)
 		r.Get("/{id}", productHandler.GetProductByID)
-		r.Put("/{id}", productHandler.UpdateProduct)
-		r.Delete("/{id}", productHandler.DeleteProduct)
+		r.With(managerOnly).Put("/{id}", productHandler.UpdateProduct)
+		r.With(managerOnly).Delete("/{id}", productHandler.DeleteProduct)
+		r.With(managerOnly).Get("/{id}/stock-movements", stockHandler.GetStockMovements)
+		r.With(managerOnly).Post("/{id}/stock-adjustments", stockHandler.AdjustStock)
+	})
+}
+
+func (rt *Router) RegisterStockTakeRoutes() {
+	stockTakeRepo := repositories.NewStockTakeRepository(rt.db)
+	stockTakeService := services.NewStockTakeService(stockTakeRepo)
+	stockTakeHandler := handler.NewStockTakeHandler(stockTakeService)
+
+	rt.router.Route("/stock-takes", func(r chi.Router) {
+		r.Use(rt.auth.Authenticate)
+		r.Get("/", stockTakeHandler.GetAllStockTakes)
+		r.With(managerOnly).Post("/", stockTakeHandler.CreateStockTake)
+		r.Get("/{id}", stockTakeHandler.GetStockTakeReport)
+		r.Post("/{id}/counts", stockTakeHandler.SubmitCounts)
+		r.With(managerOnly).Post("/{id}/finalize", stockTakeHandler.FinalizeStockTake)
+		r.With(managerOnly).Post("/{id}/cancel", stockTakeHandler.CancelStockTake)
+	})
+}
+
+func (rt *Router) RegisterInventoryRoutes() {
+	inventoryRepo := repositories.NewInventoryRepository(rt.db)
+	inventoryService := services.NewInventoryService(inventoryRepo, rt.config.LowStockNotifier)
+	inventoryHandler := handler.NewInventoryHandler(inventoryService)
+
+	rt.router.Route("/inventory", func(r chi.Router) {
+		r.Use(rt.auth.Authenticate, managerOnly)
+		r.Get("/low-stock", inventoryHandler.GetLowStockProducts)
+	})
+}
+
+func (rt *Router) RegisterSupplierRoutes() {
+	supplierRepo := repositories.NewSupplierRepository(rt.db)
+	supplierService := services.NewSupplierService(supplierRepo)
+	supplierHandler := handler.NewSupplierHandler(supplierService)
+
+	rt.router.Route("/suppliers", func(r chi.Router) {
+		r.Use(rt.auth.Authenticate, managerOnly)
+		r.Get("/", supplierHandler.GetAllSuppliers)
+		r.Post("/", supplierHandler.CreateSupplier)
+		r.Get("/{id}", supplierHandler.GetSupplierByID)
+		r.Put("/{id}", supplierHandler.UpdateSupplier)
+		r.Delete("/{id}", supplierHandler.DeleteSupplier)
+	})
+}
+
+func (rt *Router) RegisterPurchaseOrderRoutes() {
+	purchaseOrderRepo := repositories.NewPurchaseOrderRepository(rt.db, rt.config.CostMethod)
+	purchaseOrderService := services.NewPurchaseOrderService(purchaseOrderRepo)
+	purchaseOrderHandler := handler.NewPurchaseOrderHandler(purchaseOrderService)
+
+	rt.router.Route("/purchase-orders", func(r chi.Router) {
+		r.Use(rt.auth.Authenticate, managerOnly)
+		r.Get("/", purchaseOrderHandler.GetAllPurchaseOrders)
+		r.Post("/", purchaseOrderHandler.CreatePurchaseOrder)
+		r.Get("/{id}", purchaseOrderHandler.GetPurchaseOrderByID)
+		r.Put("/{id}", purchaseOrderHandler.UpdatePurchaseOrder)
+		r.Post("/{id}/order", purchaseOrderHandler.OrderPurchaseOrder)
+		r.Post("/{id}/cancel", purchaseOrderHandler.CancelPurchaseOrder)
+		r.Post("/{id}/receipts", purchaseOrderHandler.ReceiveGoods)
 	})
 }
 
 func (rt *Router) RegisterTransactionRoutes() {
-	transactionRepo := repositories.NewTransactionRepository(rt.db)
-	transactionService := services.NewTransactionService(transactionRepo)
+	transactionRepo := repositories.NewTransactionRepository(rt.db, rt.config.Tax)
+	inventoryService := services.NewInventoryService(repositories.NewInventoryRepository(rt.db), rt.config.LowStockNotifier)
+	transactionService := services.NewTransactionService(transactionRepo, inventoryService, rt.config.Location)
 	transactionHandler := handler.NewTransactionHandler(transactionService)
+	receiptService := services.NewReceiptService(transactionRepo, repositories.NewUserRepository(rt.db), rt.config.Receipt, rt.config.Location)
+	receiptHandler := handler.NewReceiptHandler(receiptService)
 
 	rt.router.Route("/transactions", func(r chi.Router) {
+		r.Use(rt.auth.Authenticate)
+		r.Get("/", transactionHandler.GetAllTransactions)
+		r.Get("/{id}", transactionHandler.GetTransactionByID)
+		r.Get("/{id}/receipt", receiptHandler.GetReceipt)
 		r.Post("/checkout", transactionHandler.Checkout)
+		r.With(managerOnly).Post("/{id}/void", transactionHandler.VoidTransaction)
+		r.With(managerOnly).Post("/{id}/refunds", transactionHandler.RefundTransaction)
+	})
+}
+
+func (rt *Router) RegisterShiftRoutes() {
+	shiftRepo := repositories.NewShiftRepository(rt.db)
+	shiftService := services.NewShiftService(shiftRepo)
+	shiftHandler := handler.NewShiftHandler(shiftService)
+
+	rt.router.Route("/shifts", func(r chi.Router) {
+		r.Use(rt.auth.Authenticate)
+		r.Get("/", shiftHandler.GetAllShifts)
+		r.Post("/", shiftHandler.OpenShift)
+		r.Get("/current", shiftHandler.GetCurrentShift)
+		r.Get("/{id}", shiftHandler.GetShiftReport)
+		r.Post("/{id}/cash-movements", shiftHandler.AddCashMovement)
+		r.Post("/{id}/close", shiftHandler.CloseShift)
+	})
+}
+
+func (rt *Router) RegisterPromotionRoutes() {
+	promotionRepo := repositories.NewPromotionRepository(rt.db)
+	promotionService := services.NewPromotionService(promotionRepo)
+	promotionHandler := handler.NewPromotionHandler(promotionService)
+
+	rt.router.Route("/promotions", func(r chi.Router) {
+		r.Use(rt.auth.Authenticate)
+		r.Get("/", promotionHandler.GetAllPromotions)
+		r.With(managerOnly).Post("/", promotionHandler.CreatePromotion)
+		r.Get("/{id}", promotionHandler.GetPromotionByID)
+		r.With(managerOnly).Put("/{id}", promotionHandler.UpdatePromotion)
+		r.With(managerOnly).Delete("/{id}", promotionHandler.DeletePromotion)
 	})
 }
 
 func (rt *Router) RegisterReportRoutes() {
-	reportRepo := repositories.NewReportRepository(rt.db)
-	reportService := services.NewReportService(reportRepo)
+	reportRepo := repositories.NewReportRepository(rt.db, rt.config.Location)
+	reportService := services.NewReportService(reportRepo, rt.config.Location)
 	reportHandler := handler.NewReportHandler(reportService)
 
 	rt.router.Route("/report", func(r chi.Router) {
+		r.Use(rt.auth.Authenticate, managerOnly)
 		r.Get("/", reportHandler.RangeReport)
 		r.Get("/today", reportHandler.TodayReport)
+		r.Get("/tax", reportHandler.TaxReport)
+		r.Get("/timeseries", reportHandler.TimeSeriesReport)
+	})
+}
+
+func (rt *Router) RegisterAuditRoutes() {
+	auditRepo := repositories.NewAuditRepository(rt.db)
+	auditService := services.NewAuditService(auditRepo, rt.config.Location)
+	auditHandler := handler.NewAuditHandler(auditService)
+
+	rt.router.Route("/audit", func(r chi.Router) {
+		r.Use(rt.auth.Authenticate, ownerOnly)
+		r.Get("/", auditHandler.GetAllAuditLogs)
 	})
 }
 
 func (rt *Router) RegisterAllRoutes() {
+	rt.RegisterAuthRoutes()
+	rt.RegisterUserRoutes()
 	rt.RegisterCategoryRoutes()
 	rt.RegisterProductRoutes()
+	rt.RegisterStockTakeRoutes()
+	rt.RegisterInventoryRoutes()
+	rt.RegisterSupplierRoutes()
+	rt.RegisterPurchaseOrderRoutes()
 	rt.RegisterTransactionRoutes()
+	rt.RegisterShiftRoutes()
+	rt.RegisterPromotionRoutes()
 	rt.RegisterReportRoutes()
+	rt.RegisterAuditRoutes()
 }
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"time"
+
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/repositories"
+)
+
+type AuditService struct {
+	repo *repositories.AuditRepository
+	loc  *time.Location
+}
+
+// NewAuditService membuat AuditService; loc adalah zona waktu toko untuk
+// filter tanggal
+func NewAuditService(repo *repositories.AuditRepository, loc *time.Location) *AuditService {
+	return &AuditService{
+		repo: repo,
+		loc:  loc,
+	}
+}
+
+func (s *AuditService) GetAllAuditLogs(filter models.AuditFilter) (*models.AuditListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 50
+	}
+	if filter.Limit > 200 {
+		filter.Limit = 200
+	}
+
+	if err := validateUUIDs(uuidField{"actor_id", filter.ActorID}); err != nil {
+		return nil, err
+	}
+
+	var err error
+	filter.CreatedFrom, filter.CreatedTo, err = parseDateRange(s.loc, filter.StartDate, filter.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, total, err := s.repo.GetAllAuditLogs(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuditListResponse{
+		Data:  logs,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+		Total: total,
+	}, nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+	"labkoding.my.id/kasir-api/apperror"
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/repositories"
+)
+
+var (
+	ErrInvalidCredentials = apperror.Unauthorized("INVALID_CREDENTIALS", "username atau password salah")
+	ErrInvalidToken       = apperror.Unauthorized("INVALID_TOKEN", "token tidak valid atau sudah kedaluwarsa")
+)
+
+// dummyPasswordHash dibandingkan saat username tidak ditemukan agar waktu
+// respon login tidak membedakan username yang ada dan yang tidak.
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("kasir-api-dummy-password"), bcrypt.DefaultCost)
+
+type tokenClaims struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+type AuthService struct {
+	repo   *repositories.UserRepository
+	config models.AuthConfig
+}
+
+func NewAuthService(repo *repositories.UserRepository, config models.AuthConfig) *AuthService {
+	return &AuthService{
+		repo:   repo,
+		config: config,
+	}
+}
+
+// Login memeriksa username dan password lalu menerbitkan token HS256
+func (s *AuthService) Login(req models.LoginRequest) (*models.LoginResponse, error) {
+	user, err := s.repo.GetUserByUsername(req.Username)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if !user.Active {
+		return nil, ErrInvalidCredentials
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.config.TokenTTL)
+	claims := tokenClaims{
+		Username: user.Username,
+		Role:     user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   user.ID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.config.Secret))
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LoginResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      *user,
+	}, nil
+}
+
+// Authenticate memverifikasi token lalu memuat user dari database, sehingga
+// user yang dinonaktifkan atau diganti role-nya langsung berlaku tanpa
+// menunggu token kedaluwarsa.
+func (s *AuthService) Authenticate(token string) (*models.AuthUser, error) {
+	var claims tokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(s.config.Secret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, ErrInvalidToken.Wrap(err)
+	}
+
+	user, err := s.repo.GetUserByID(claims.Subject)
+	if errors.Is(err, repositories.ErrUserNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !user.Active {
+		return nil, ErrInvalidToken
+	}
+
+	return &models.AuthUser{
+		ID:       user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+	}, nil
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	errBarcodeLength     = errors.New("barcode harus 12 digit (UPC-A) atau 13 digit (EAN-13)")
+	errBarcodeCheckDigit = errors.New("check digit barcode tidak valid")
+)
+
+// normalizeBarcode memvalidasi barcode EAN-13 atau UPC-A beserta check
+// digit-nya. UPC-A dikembalikan sebagai EAN-13 dengan awalan 0 karena
+// keduanya kode yang sama dan scanner bisa membaca salah satunya.
+func normalizeBarcode(code string) (string, error) {
+	code = strings.TrimSpace(code)
+	if len(code) == 12 {
+		code = "0" + code
+	}
+	if len(code) != 13 {
+		return "", errBarcodeLength
+	}
+
+	sum := 0
+	for i := range 13 {
+		if code[i] < '0' || code[i] > '9' {
+			return "", errBarcodeLength
+		}
+		if i == 12 {
+			break
+		}
+		digit := int(code[i] - '0')
+		// digit pada posisi genap berbobot 1, ganjil berbobot 3
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	if check := (10 - sum%10) % 10; check != int(code[12]-'0') {
+		return "", errBarcodeCheckDigit
+	}
+	return code, nil
+}
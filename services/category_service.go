@@ -15,22 +15,22 @@ func NewCategoryService(repo *repositories.CategoryRepository) *CategoryService
 	}
 }
 
-func (s *CategoryService) GetAllCategories(name string) ([]models.CategoryResponse, error) {
-	return s.repo.GetAllCategories(name)
+func (s *CategoryService) GetAllCategories(name string, includeProducts bool) ([]models.CategoryResponse, error) {
+	return s.repo.GetAllCategories(name, includeProducts)
 }
 
-func (s *CategoryService) CreateCategory(category *models.CategoryRequest) error {
-	return s.repo.CreateCategory(category)
+func (s *CategoryService) CreateCategory(category *models.CategoryRequest, actor *models.AuthUser) error {
+	return s.repo.CreateCategory(category, actor)
 }
 
 func (s *CategoryService) GetCategoryByID(id string) (*models.Category, error) {
 	return s.repo.GetCategoryByID(id)
 }
 
-func (s *CategoryService) UpdateCategory(category *models.CategoryRequest) error {
-	return s.repo.UpdateCategory(category)
+func (s *CategoryService) UpdateCategory(category *models.CategoryRequest, actor *models.AuthUser) error {
+	return s.repo.UpdateCategory(category, actor)
 }
 
-func (s *CategoryService) DeleteCategory(id string) error {
-	return s.repo.DeleteCategory(id)
+func (s *CategoryService) DeleteCategory(id string, actor *models.AuthUser) error {
+	return s.repo.DeleteCategory(id, actor)
 }
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"time"
+
+	"labkoding.my.id/kasir-api/apperror"
+)
+
+const dateLayout = "2006-01-02"
+
+// parseDateRange mengubah start_date dan end_date (YYYY-MM-DD, inklusif)
+// menjadi rentang [from, to) di zona waktu toko. Tanggal yang kosong
+// menghasilkan batas nil.
+func parseDateRange(loc *time.Location, startDate, endDate string) (from, to *time.Time, err error) {
+	if startDate != "" {
+		start, err := time.ParseInLocation(dateLayout, startDate, loc)
+		if err != nil {
+			return nil, nil, apperror.ErrInvalidQuery.WithMessage("start_date harus berformat YYYY-MM-DD")
+		}
+		from = &start
+	}
+	if endDate != "" {
+		end, err := time.ParseInLocation(dateLayout, endDate, loc)
+		if err != nil {
+			return nil, nil, apperror.ErrInvalidQuery.WithMessage("end_date harus berformat YYYY-MM-DD")
+		}
+		// AddDate memakai tanggal kalender sehingga tetap tepat saat pergantian DST
+		end = end.AddDate(0, 0, 1)
+		to = &end
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, nil, apperror.ErrInvalidQuery.WithMessage("end_date tidak boleh sebelum start_date")
+	}
+	return from, to, nil
+}
+
+// requireDateRange sama dengan parseDateRange tetapi start_date dan end_date
+// wajib diisi
+func requireDateRange(loc *time.Location, startDate, endDate string) (from, to time.Time, err error) {
+	if startDate == "" || endDate == "" {
+		return time.Time{}, time.Time{}, apperror.ErrInvalidQuery.WithMessage("start_date dan end_date wajib diisi dengan format YYYY-MM-DD")
+	}
+	start, end, err := parseDateRange(loc, startDate, endDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return *start, *end, nil
+}
+
+// todayRange mengembalikan rentang [from, to) untuk hari ini di zona waktu toko
+func todayRange(loc *time.Location) (from, to time.Time) {
+	y, m, d := time.Now().In(loc).Date()
+	from = time.Date(y, m, d, 0, 0, 0, 0, loc)
+	return from, from.AddDate(0, 0, 1)
+}
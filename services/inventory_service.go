@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/repositories"
+)
+
+// lowStockNotifyTimeout membatasi waktu pengiriman notifikasi stok menipis
+// setelah checkout
+const lowStockNotifyTimeout = 30 * time.Second
+
+// LowStockNotifier mengirim event stok menipis ke kanal notifikasi, misalnya
+// log, webhook atau email
+type LowStockNotifier interface {
+	NotifyLowStock(ctx context.Context, event models.LowStockEvent) error
+}
+
+type InventoryService struct {
+	repo     *repositories.InventoryRepository
+	notifier LowStockNotifier
+}
+
+func NewInventoryService(repo *repositories.InventoryRepository, notifier LowStockNotifier) *InventoryService {
+	return &InventoryService{
+		repo:     repo,
+		notifier: notifier,
+	}
+}
+
+func (s *InventoryService) GetLowStockProducts(filter models.LowStockFilter) (*models.LowStockListResponse, error) {
+	if err := validateUUIDs(uuidField{"category_id", filter.CategoryID}); err != nil {
+		return nil, err
+	}
+	products, err := s.repo.GetLowStockProducts(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LowStockListResponse{
+		Data:  products,
+		Total: len(products),
+	}, nil
+}
+
+// NotifyLowStock dipanggil setelah checkout berhasil dengan produk yang
+// stoknya turun melewati reorder point di dalam transaksi checkout tersebut.
+// Pengiriman berjalan di background agar checkout tidak menunggu notifier;
+// kegagalan hanya dicatat di log.
+func (s *InventoryService) NotifyLowStock(transactionID string, products []models.LowStockProduct) {
+	if s.notifier == nil || len(products) == 0 {
+		return
+	}
+
+	event := models.LowStockEvent{
+		TransactionID: transactionID,
+		Products:      products,
+		CreatedAt:     time.Now(),
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), lowStockNotifyTimeout)
+		defer cancel()
+
+		if err := s.notifier.NotifyLowStock(ctx, event); err != nil {
+			slog.Error("low_stock_notify_failed", slog.String("transaction_id", transactionID), slog.String("error", err.Error()))
+		}
+	}()
+}
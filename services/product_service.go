@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"labkoding.my.id/kasir-api/apperror"
 	"labkoding.my.id/kasir-api/external"
 	"labkoding.my.id/kasir-api/models"
 	"labkoding.my.id/kasir-api/repositories"
@@ -19,32 +20,169 @@ import (
 
 type ProductService struct {
 	repo *repositories.ProductRepository
+	loc  *time.Location
 }
 
-func NewProductService(repo *repositories.ProductRepository) *ProductService {
+// NewProductService membuat ProductService; loc adalah zona waktu toko untuk
+// tanggal di file ekspor
+func NewProductService(repo *repositories.ProductRepository, loc *time.Location) *ProductService {
 	return &ProductService{
 		repo: repo,
+		loc:  loc,
 	}
 }
 
-func (s *ProductService) GetAllProducts(name string) ([]models.Product, error) {
-	return s.repo.GetAllProducts(name)
+const (
+	defaultProductLimit = 50
+	maxProductLimit     = 200
+)
+
+func (s *ProductService) GetAllProducts(filter models.ProductFilter) (*models.ProductListResponse, error) {
+	if err := validateUUIDs(uuidField{"category_id", filter.CategoryID}); err != nil {
+		return nil, err
+	}
+	if filter.Sort == "" {
+		filter.Sort = "name"
+	}
+	if filter.Limit < 1 {
+		filter.Limit = defaultProductLimit
+	}
+	if filter.Limit > maxProductLimit {
+		filter.Limit = maxProductLimit
+	}
+
+	products, total, nextCursor, err := s.repo.GetAllProducts(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ProductListResponse{
+		Data:  products,
+		Total: total,
+		Limit: filter.Limit,
+	}
+	if nextCursor != "" {
+		response.NextCursor = &nextCursor
+	}
+	return response, nil
 }
 
-func (s *ProductService) CreateProduct(product *models.Product) error {
-	return s.repo.CreateProduct(product)
+// ExportProducts memanggil fn untuk setiap produk yang cocok dengan filter,
+// tanpa pagination. created_at ditulis di zona waktu toko.
+func (s *ProductService) ExportProducts(filter models.ProductFilter, fn func(models.Product) error) error {
+	if err := validateUUIDs(uuidField{"category_id", filter.CategoryID}); err != nil {
+		return err
+	}
+	if filter.Sort == "" {
+		filter.Sort = "name"
+	}
+	return s.repo.ExportProducts(filter, func(product models.Product) error {
+		product.CreatedAt = product.CreatedAt.In(s.loc)
+		return fn(product)
+	})
+}
+
+// validateProductAmounts menolak HPP dan titik pemesanan ulang yang negatif
+func validateProductAmounts(product *models.Product) error {
+	fieldErrors := []models.FieldError{}
+	for _, field := range []struct {
+		name  string
+		value *int
+	}{
+		{"cost_price", product.CostPrice},
+		{"reorder_point", product.ReorderPoint},
+		{"reorder_qty", product.ReorderQty},
+	} {
+		if field.value != nil && *field.value < 0 {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: field.name, Message: field.name + " tidak boleh negatif"})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return validationError(fieldErrors)
+	}
+	return nil
+}
+
+const maxSKULength = 64
+
+// validateProductCodes merapikan SKU dan menormalkan setiap barcode ke
+// EAN-13. Barcode yang tidak valid atau ganda ditolak per indeks.
+func validateProductCodes(product *models.Product) error {
+	fieldErrors := []models.FieldError{}
+	if product.SKU != nil {
+		sku := strings.TrimSpace(*product.SKU)
+		product.SKU = &sku
+		if len(sku) > maxSKULength {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: "sku", Message: fmt.Sprintf("sku maksimal %d karakter", maxSKULength)})
+		}
+	}
+
+	seen := make(map[string]bool, len(product.Barcodes))
+	for i, code := range product.Barcodes {
+		field := fmt.Sprintf("barcodes[%d]", i)
+		barcode, err := normalizeBarcode(code)
+		if err != nil {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: field, Message: err.Error()})
+			continue
+		}
+		if seen[barcode] {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: field, Message: "barcode duplikat"})
+			continue
+		}
+		seen[barcode] = true
+		product.Barcodes[i] = barcode
+	}
+
+	if len(fieldErrors) > 0 {
+		return validationError(fieldErrors)
+	}
+	return nil
+}
+
+func (s *ProductService) CreateProduct(product *models.Product, actor *models.AuthUser) error {
+	if err := validateProductAmounts(product); err != nil {
+		return err
+	}
+	if err := validateProductCodes(product); err != nil {
+		return err
+	}
+	return s.repo.CreateProduct(product, actor)
 }
 
 func (s *ProductService) GetProductByID(id string) (*models.Product, error) {
 	return s.repo.GetProductByID(id)
 }
 
-func (s *ProductService) UpdateProduct(product *models.Product) error {
-	return s.repo.UpdateProduct(product)
+// LookupProduct mencari satu produk berdasarkan barcode atau SKU yang sama
+// persis. Tepat satu dari keduanya harus diisi.
+func (s *ProductService) LookupProduct(barcode, sku string) (*models.Product, error) {
+	barcode, sku = strings.TrimSpace(barcode), strings.TrimSpace(sku)
+	if (barcode == "") == (sku == "") {
+		return nil, apperror.ErrInvalidQuery.WithMessage("isi salah satu dari barcode atau sku")
+	}
+	if sku != "" {
+		return s.repo.GetProductBySKU(sku)
+	}
+
+	code, err := normalizeBarcode(barcode)
+	if err != nil {
+		return nil, apperror.ErrInvalidQuery.WithMessage(err.Error())
+	}
+	return s.repo.GetProductByBarcode(code)
+}
+
+func (s *ProductService) UpdateProduct(product *models.Product, actor *models.AuthUser) error {
+	if err := validateProductAmounts(product); err != nil {
+		return err
+	}
+	if err := validateProductCodes(product); err != nil {
+		return err
+	}
+	return s.repo.UpdateProduct(product, actor)
 }
 
-func (s *ProductService) DeleteProduct(id string) error {
-	return s.repo.DeleteProduct(id)
+func (s *ProductService) DeleteProduct(id string, actor *models.AuthUser) error {
+	return s.repo.DeleteProduct(id, actor)
 }
 
 // UploadProductImage uploads an image reader to configured R2 and returns the public URL.
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"slices"
+
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/repositories"
+)
+
+type PromotionService struct {
+	repo *repositories.PromotionRepository
+}
+
+func NewPromotionService(repo *repositories.PromotionRepository) *PromotionService {
+	return &PromotionService{
+		repo: repo,
+	}
+}
+
+func (s *PromotionService) GetAllPromotions(activeOnly bool) ([]models.Promotion, error) {
+	return s.repo.GetAllPromotions(activeOnly)
+}
+
+func (s *PromotionService) GetPromotionByID(id string) (*models.Promotion, error) {
+	return s.repo.GetPromotionByID(id)
+}
+
+func (s *PromotionService) CreatePromotion(promotion *models.Promotion) error {
+	if err := validatePromotion(promotion); err != nil {
+		return err
+	}
+	return s.repo.CreatePromotion(promotion)
+}
+
+func (s *PromotionService) UpdatePromotion(promotion *models.Promotion) error {
+	if err := validatePromotion(promotion); err != nil {
+		return err
+	}
+	return s.repo.UpdatePromotion(promotion)
+}
+
+func (s *PromotionService) DeletePromotion(id string) error {
+	return s.repo.DeletePromotion(id)
+}
+
+func validatePromotion(promotion *models.Promotion) error {
+	fieldErrors := make([]models.FieldError, 0)
+	addError := func(field, message string) {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: field, Message: message})
+	}
+
+	if promotion.Name == "" {
+		addError("name", "name wajib diisi")
+	}
+
+	types := []string{models.PromotionTypePercentage, models.PromotionTypeFixed, models.PromotionTypeBuyXGetY}
+	if !slices.Contains(types, promotion.Type) {
+		addError("type", "type harus percentage, fixed atau buy_x_get_y")
+	}
+
+	switch promotion.Scope {
+	case models.PromotionScopeProduct:
+		if promotion.ProductID == nil || *promotion.ProductID == "" {
+			addError("product_id", "product_id wajib diisi untuk scope product")
+		} else if !isUUID(*promotion.ProductID) {
+			addError("product_id", "product_id harus berupa UUID")
+		}
+		promotion.CategoryID = nil
+	case models.PromotionScopeCategory:
+		if promotion.CategoryID == nil || *promotion.CategoryID == "" {
+			addError("category_id", "category_id wajib diisi untuk scope category")
+		} else if !isUUID(*promotion.CategoryID) {
+			addError("category_id", "category_id harus berupa UUID")
+		}
+		promotion.ProductID = nil
+	case models.PromotionScopeCart:
+		if promotion.Type == models.PromotionTypeBuyXGetY {
+			addError("type", "buy_x_get_y tidak berlaku untuk scope cart")
+		}
+		if promotion.MinSpend < 0 {
+			addError("min_spend", "min_spend tidak boleh negatif")
+		}
+		promotion.ProductID = nil
+		promotion.CategoryID = nil
+	default:
+		addError("scope", "scope harus product, category atau cart")
+	}
+
+	switch promotion.Type {
+	case models.PromotionTypePercentage:
+		if promotion.Value < 1 || promotion.Value > 100 {
+			addError("value", "value persentase harus antara 1 dan 100")
+		}
+	case models.PromotionTypeFixed:
+		if promotion.Value <= 0 {
+			addError("value", "value harus lebih dari 0")
+		}
+	case models.PromotionTypeBuyXGetY:
+		if promotion.BuyQty <= 0 {
+			addError("buy_qty", "buy_qty harus lebih dari 0")
+		}
+		if promotion.GetQty <= 0 {
+			addError("get_qty", "get_qty harus lebih dari 0")
+		}
+	}
+
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		addError("ends_at", "ends_at harus setelah starts_at")
+	}
+
+	if len(fieldErrors) > 0 {
+		return validationError(fieldErrors)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/repositories"
+)
+
+type PurchaseOrderService struct {
+	repo *repositories.PurchaseOrderRepository
+}
+
+func NewPurchaseOrderService(repo *repositories.PurchaseOrderRepository) *PurchaseOrderService {
+	return &PurchaseOrderService{
+		repo: repo,
+	}
+}
+
+func (s *PurchaseOrderService) GetAllPurchaseOrders(filter models.PurchaseOrderFilter) ([]models.PurchaseOrder, error) {
+	if err := validateUUIDs(uuidField{"supplier_id", filter.SupplierID}); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAllPurchaseOrders(filter)
+}
+
+func (s *PurchaseOrderService) GetPurchaseOrderByID(id string) (*models.PurchaseOrder, error) {
+	return s.repo.GetPurchaseOrderByID(id)
+}
+
+func (s *PurchaseOrderService) CreatePurchaseOrder(req models.PurchaseOrderRequest, actor *models.AuthUser) (*models.PurchaseOrder, error) {
+	if err := validatePurchaseOrder(req); err != nil {
+		return nil, err
+	}
+
+	id, err := s.repo.CreatePurchaseOrder(req, actor)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPurchaseOrderByID(id)
+}
+
+func (s *PurchaseOrderService) UpdatePurchaseOrder(id string, req models.PurchaseOrderRequest) (*models.PurchaseOrder, error) {
+	if err := validatePurchaseOrder(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdatePurchaseOrder(id, req); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPurchaseOrderByID(id)
+}
+
+// OrderPurchaseOrder menandai draft sudah dikirim ke supplier
+func (s *PurchaseOrderService) OrderPurchaseOrder(id string) (*models.PurchaseOrder, error) {
+	if err := s.repo.UpdateStatus(id, models.PurchaseOrderStatusOrdered, models.PurchaseOrderStatusDraft); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPurchaseOrderByID(id)
+}
+
+// CancelPurchaseOrder membatalkan sisa pesanan. Barang yang sudah diterima
+// tetap tercatat di stok.
+func (s *PurchaseOrderService) CancelPurchaseOrder(id string) (*models.PurchaseOrder, error) {
+	err := s.repo.UpdateStatus(id, models.PurchaseOrderStatusCancelled,
+		models.PurchaseOrderStatusDraft, models.PurchaseOrderStatusOrdered, models.PurchaseOrderStatusPartiallyReceived)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPurchaseOrderByID(id)
+}
+
+func (s *PurchaseOrderService) ReceiveGoods(id string, req models.GoodsReceiptRequest, actor *models.AuthUser) (*models.GoodsReceipt, error) {
+	if len(req.Items) == 0 {
+		return nil, validationError([]models.FieldError{
+			{Field: "items", Message: "items tidak boleh kosong"},
+		})
+	}
+
+	fieldErrors := make([]models.FieldError, 0)
+	for i, item := range req.Items {
+		if item.ProductID == "" {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].product_id", i),
+				Message: "product_id wajib diisi",
+			})
+		}
+		if item.Quantity <= 0 {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].quantity", i),
+				Message: "quantity harus lebih dari 0",
+			})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return nil, validationError(fieldErrors)
+	}
+
+	merged := make([]models.GoodsReceiptItemInput, 0, len(req.Items))
+	index := make(map[string]int)
+	for _, item := range req.Items {
+		if i, ok := index[item.ProductID]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.ProductID] = len(merged)
+		merged = append(merged, item)
+	}
+	// urutan product_id yang konsisten dengan checkout supaya tidak deadlock
+	sort.Slice(merged, func(i, j int) bool { return merged[i].ProductID < merged[j].ProductID })
+	req.Items = merged
+
+	return s.repo.ReceiveGoods(id, req, actor)
+}
+
+func validatePurchaseOrder(req models.PurchaseOrderRequest) error {
+	fieldErrors := make([]models.FieldError, 0)
+	if req.SupplierID == "" {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "supplier_id", Message: "supplier_id wajib diisi"})
+	} else if !isUUID(req.SupplierID) {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "supplier_id", Message: "supplier_id harus berupa UUID"})
+	}
+	if len(req.Items) == 0 {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "items", Message: "items tidak boleh kosong"})
+	}
+
+	seen := make(map[string]bool)
+	for i, item := range req.Items {
+		if item.ProductID == "" {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].product_id", i),
+				Message: "product_id wajib diisi",
+			})
+		} else if !isUUID(item.ProductID) {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].product_id", i),
+				Message: "product_id harus berupa UUID",
+			})
+		} else if seen[item.ProductID] {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].product_id", i),
+				Message: "product_id tidak boleh duplikat",
+			})
+		}
+		seen[item.ProductID] = true
+		if item.Quantity <= 0 {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].quantity", i),
+				Message: "quantity harus lebih dari 0",
+			})
+		}
+		if item.CostPrice < 0 {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].cost_price", i),
+				Message: "cost_price tidak boleh kurang dari 0",
+			})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return validationError(fieldErrors)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"labkoding.my.id/kasir-api/apperror"
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/repositories"
+)
+
+type ReceiptService struct {
+	transactions *repositories.TransactionRepository
+	users        *repositories.UserRepository
+	config       models.ReceiptConfig
+	loc          *time.Location
+}
+
+// NewReceiptService membuat ReceiptService; loc adalah zona waktu toko untuk
+// tanggal yang dicetak di struk
+func NewReceiptService(transactions *repositories.TransactionRepository, users *repositories.UserRepository, config models.ReceiptConfig, loc *time.Location) *ReceiptService {
+	return &ReceiptService{
+		transactions: transactions,
+		users:        users,
+		config:       config,
+		loc:          loc,
+	}
+}
+
+// GetReceipt memuat transaksi untuk dicetak. paperWidth 0 berarti memakai
+// lebar kertas dari konfigurasi.
+func (s *ReceiptService) GetReceipt(id string, paperWidth int) (*models.Receipt, error) {
+	if paperWidth == 0 {
+		paperWidth = s.config.PaperWidth
+	}
+	if paperWidth != models.PaperWidth58 && paperWidth != models.PaperWidth80 {
+		return nil, apperror.ErrInvalidQuery.WithMessage("width harus 58 atau 80")
+	}
+
+	transaction, err := s.transactions.GetTransactionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	// repository membaca waktu sebagai timestamptz, jadi cukup dipindah ke
+	// zona waktu toko
+	transaction.CreatedAt = transaction.CreatedAt.In(s.loc)
+	for i := range transaction.Refunds {
+		transaction.Refunds[i].CreatedAt = transaction.Refunds[i].CreatedAt.In(s.loc)
+	}
+
+	receipt := &models.Receipt{
+		Config:      s.config,
+		Transaction: *transaction,
+		PaperWidth:  paperWidth,
+	}
+	if transaction.CashierID != nil {
+		// kasir yang sudah dihapus tidak menggagalkan cetak struk
+		user, err := s.users.GetUserByID(*transaction.CashierID)
+		if err != nil && !errors.Is(err, repositories.ErrUserNotFound) {
+			return nil, err
+		}
+		if user != nil {
+			receipt.CashierUsername = user.Username
+		}
+	}
+
+	return receipt, nil
+}
@@ -1,24 +1,157 @@
 package services
 
 import (
+	"math"
+	"time"
+
+	"labkoding.my.id/kasir-api/apperror"
 	"labkoding.my.id/kasir-api/models"
 	"labkoding.my.id/kasir-api/repositories"
 )
 
 type ReportService struct {
 	repo *repositories.ReportRepository
+	loc  *time.Location
 }
 
-func NewReportService(repo *repositories.ReportRepository) *ReportService {
+// NewReportService membuat ReportService; loc adalah zona waktu toko yang
+// dipakai untuk menentukan awal hari, minggu dan bulan
+func NewReportService(repo *repositories.ReportRepository, loc *time.Location) *ReportService {
 	return &ReportService{
 		repo: repo,
+		loc:  loc,
 	}
 }
 
-func (s *ReportService) TodayReport() (models.Report, error) {
-	return s.repo.TodayReport()
+const (
+	defaultReportTop = 5
+	maxReportTop     = 100
+)
+
+// reportTop membatasi jumlah baris peringkat produk dan kategori terlaris
+func reportTop(top int) int {
+	if top < 1 {
+		return defaultReportTop
+	}
+	if top > maxReportTop {
+		return maxReportTop
+	}
+	return top
 }
 
-func (s *ReportService) RangeReport(startDate, endDate string) (models.Report, error) {
-	return s.repo.Range(startDate, endDate)
+// TodayReport merangkum penjualan hari ini di zona waktu toko
+func (s *ReportService) TodayReport(top int) (models.Report, error) {
+	from, to := todayRange(s.loc)
+	return s.repo.Summary(from, to, reportTop(top))
+}
+
+func (s *ReportService) RangeReport(startDate, endDate string, top int) (models.Report, error) {
+	from, to, err := requireDateRange(s.loc, startDate, endDate)
+	if err != nil {
+		return models.Report{}, err
+	}
+	return s.repo.Summary(from, to, reportTop(top))
+}
+
+func (s *ReportService) TaxReport(startDate, endDate string) (models.TaxReport, error) {
+	from, to, err := requireDateRange(s.loc, startDate, endDate)
+	if err != nil {
+		return models.TaxReport{}, err
+	}
+
+	report, err := s.repo.TaxReport(from, to)
+	if err != nil {
+		return models.TaxReport{}, err
+	}
+	report.StartDate = startDate
+	report.EndDate = endDate
+	return report, nil
+}
+
+// maxTimeSeriesBuckets membatasi jumlah bucket dalam satu laporan time series
+const maxTimeSeriesBuckets = 1000
+
+// TimeSeries merangkum penjualan per bucket untuk tanggal startDate sampai
+// endDate (inklusif) di zona waktu toko. Bucket tanpa penjualan tetap
+// dikembalikan dengan nilai 0.
+func (s *ReportService) TimeSeries(granularity, startDate, endDate string) (*models.TimeSeriesReport, error) {
+	from, to, err := requireDateRange(s.loc, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	bucketStarts := make([]time.Time, 0)
+	for t := truncateBucket(from, granularity); t.Before(to); t = nextBucket(t, granularity) {
+		if len(bucketStarts) == maxTimeSeriesBuckets {
+			return nil, apperror.ErrInvalidQuery.WithMessage("rentang tanggal terlalu panjang untuk granularity " + granularity)
+		}
+		bucketStarts = append(bucketStarts, t)
+	}
+
+	rows, err := s.repo.TimeSeries(granularity, from, to)
+	if err != nil {
+		return nil, err
+	}
+	const keyLayout = "2006-01-02 15:04:05"
+	byStart := make(map[string]models.TimeSeriesBucket, len(rows))
+	for _, row := range rows {
+		byStart[row.Start.Format(keyLayout)] = row
+	}
+
+	report := &models.TimeSeriesReport{
+		Granularity: granularity,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Timezone:    s.loc.String(),
+		Buckets:     make([]models.TimeSeriesBucket, 0, len(bucketStarts)),
+	}
+	seen := make(map[string]bool, len(bucketStarts))
+	for _, bucketStart := range bucketStarts {
+		// saat jam mundur (DST) dua jam absolut bisa jatuh di jam lokal yang sama
+		key := bucketStart.Format(keyLayout)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		bucket := byStart[key]
+		bucket.Start = bucketStart
+		if bucket.TransactionCount > 0 {
+			bucket.AverageBasketValue = int(math.Round(float64(bucket.Revenue) / float64(bucket.TransactionCount)))
+			bucket.AverageBasketSize = math.Round(float64(bucket.ItemsSold)/float64(bucket.TransactionCount)*100) / 100
+		}
+		report.Buckets = append(report.Buckets, bucket)
+	}
+
+	return report, nil
+}
+
+// truncateBucket mengembalikan awal bucket yang memuat t, sama dengan
+// DATE_TRUNC di Postgres (minggu dimulai hari Senin)
+func truncateBucket(t time.Time, granularity string) time.Time {
+	y, m, d := t.Date()
+	switch granularity {
+	case models.GranularityHour:
+		return time.Date(y, m, d, t.Hour(), 0, 0, 0, t.Location())
+	case models.GranularityWeek:
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, t.Location())
+	case models.GranularityMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	}
+}
+
+func nextBucket(t time.Time, granularity string) time.Time {
+	switch granularity {
+	case models.GranularityHour:
+		return t.Add(time.Hour)
+	case models.GranularityWeek:
+		return t.AddDate(0, 0, 7)
+	case models.GranularityMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
 }
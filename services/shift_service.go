@@ -0,0 +1,133 @@
+package services
+
+import (
+	"strings"
+
+	"labkoding.my.id/kasir-api/apperror"
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/repositories"
+)
+
+type ShiftService struct {
+	repo *repositories.ShiftRepository
+}
+
+func NewShiftService(repo *repositories.ShiftRepository) *ShiftService {
+	return &ShiftService{
+		repo: repo,
+	}
+}
+
+// canAccessShift membatasi kasir hanya pada shift miliknya sendiri. Manager
+// dan owner boleh melihat dan menutup shift kasir mana pun.
+func canAccessShift(shift *models.Shift, actor *models.AuthUser) bool {
+	return actor.Role != models.RoleCashier || shift.CashierID == actor.ID
+}
+
+func (s *ShiftService) OpenShift(req models.OpenShiftRequest, actor *models.AuthUser) (*models.ShiftReport, error) {
+	if req.OpeningFloat < 0 {
+		return nil, validationError([]models.FieldError{
+			{Field: "opening_float", Message: "opening_float tidak boleh kurang dari 0"},
+		})
+	}
+
+	shift, err := s.repo.OpenShift(req, actor)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetShiftReport(shift.ID)
+}
+
+// GetAllShifts mengembalikan daftar shift. Kasir hanya melihat shift miliknya.
+func (s *ShiftService) GetAllShifts(filter models.ShiftFilter, actor *models.AuthUser) ([]models.Shift, error) {
+	if filter.Status != "" && filter.Status != models.ShiftStatusOpen && filter.Status != models.ShiftStatusClosed {
+		return nil, apperror.ErrInvalidQuery.WithMessage("status harus open atau closed")
+	}
+	if actor.Role == models.RoleCashier {
+		filter.CashierID = actor.ID
+	}
+	if err := validateUUIDs(uuidField{"cashier_id", filter.CashierID}); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAllShifts(filter)
+}
+
+// GetCurrentShift mengembalikan Z-report sementara dari shift open milik actor
+func (s *ShiftService) GetCurrentShift(actor *models.AuthUser) (*models.ShiftReport, error) {
+	id, err := s.repo.GetOpenShiftID(actor.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetShiftReport(id)
+}
+
+func (s *ShiftService) GetShiftReport(id string, actor *models.AuthUser) (*models.ShiftReport, error) {
+	report, err := s.repo.GetShiftReport(id)
+	if err != nil {
+		return nil, err
+	}
+	if !canAccessShift(&report.Shift, actor) {
+		return nil, repositories.ErrShiftNotFound
+	}
+	return report, nil
+}
+
+func (s *ShiftService) AddCashMovement(id string, req models.CashMovementRequest, actor *models.AuthUser) (*models.ShiftReport, error) {
+	req.Reason = strings.TrimSpace(req.Reason)
+
+	fieldErrors := make([]models.FieldError, 0)
+	if req.Type != models.CashMovementIn && req.Type != models.CashMovementOut {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "type", Message: "type harus cash_in atau cash_out"})
+	}
+	if req.Amount <= 0 {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "amount", Message: "amount harus lebih dari 0"})
+	}
+	if req.Reason == "" {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "reason", Message: "reason wajib diisi"})
+	}
+	if len(fieldErrors) > 0 {
+		return nil, validationError(fieldErrors)
+	}
+
+	if err := s.checkAccess(id, actor); err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.AddCashMovement(id, req, actor); err != nil {
+		return nil, err
+	}
+	return s.repo.GetShiftReport(id)
+}
+
+// CloseShift menutup shift dengan jumlah kas hasil hitung dan mengembalikan
+// Z-report final
+func (s *ShiftService) CloseShift(id string, req models.CloseShiftRequest, actor *models.AuthUser) (*models.ShiftReport, error) {
+	if req.CountedCash == nil {
+		return nil, validationError([]models.FieldError{
+			{Field: "counted_cash", Message: "counted_cash wajib diisi"},
+		})
+	}
+	if *req.CountedCash < 0 {
+		return nil, validationError([]models.FieldError{
+			{Field: "counted_cash", Message: "counted_cash tidak boleh kurang dari 0"},
+		})
+	}
+
+	if err := s.checkAccess(id, actor); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CloseShift(id, req, actor); err != nil {
+		return nil, err
+	}
+	return s.repo.GetShiftReport(id)
+}
+
+func (s *ShiftService) checkAccess(id string, actor *models.AuthUser) error {
+	shift, err := s.repo.GetShiftByID(id)
+	if err != nil {
+		return err
+	}
+	if !canAccessShift(shift, actor) {
+		return repositories.ErrShiftNotFound
+	}
+	return nil
+}
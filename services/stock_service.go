@@ -0,0 +1,80 @@
+package services
+
+import (
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/repositories"
+)
+
+type StockService struct {
+	repo *repositories.StockMovementRepository
+}
+
+func NewStockService(repo *repositories.StockMovementRepository) *StockService {
+	return &StockService{
+		repo: repo,
+	}
+}
+
+func (s *StockService) GetStockMovements(filter models.StockMovementFilter) (*models.StockMovementListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 50
+	}
+	if filter.Limit > 200 {
+		filter.Limit = 200
+	}
+
+	movements, total, err := s.repo.GetStockMovements(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StockMovementListResponse{
+		Data:  movements,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+		Total: total,
+	}, nil
+}
+
+// AdjustStock mencatat koreksi stok manual. Jumlah waste selalu mengurangi stok.
+func (s *StockService) AdjustStock(productID string, req models.StockAdjustmentRequest, actor *models.AuthUser) (*models.StockMovement, error) {
+	fieldErrors := make([]models.FieldError, 0)
+	switch req.Type {
+	case models.StockMovementAdjustment:
+		if req.Quantity == 0 {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: "quantity", Message: "quantity tidak boleh 0"})
+		}
+	case models.StockMovementWaste:
+		if req.Quantity <= 0 {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: "quantity", Message: "quantity harus lebih dari 0"})
+		}
+	default:
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "type", Message: "type harus adjustment atau waste"})
+	}
+	if req.Reason == "" {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "reason", Message: "alasan wajib diisi"})
+	}
+	if len(fieldErrors) > 0 {
+		return nil, validationError(fieldErrors)
+	}
+
+	quantity := req.Quantity
+	if req.Type == models.StockMovementWaste {
+		quantity = -quantity
+	}
+
+	movement := &models.StockMovement{
+		ProductID: productID,
+		Type:      req.Type,
+		Quantity:  quantity,
+		Reason:    &req.Reason,
+	}
+	if err := s.repo.CreateAdjustment(movement, actor); err != nil {
+		return nil, err
+	}
+
+	return movement, nil
+}
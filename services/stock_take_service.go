@@ -0,0 +1,98 @@
+package services
+
+import (
+	"fmt"
+
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/repositories"
+)
+
+// maxStockTakeBatch membatasi jumlah baris dalam satu batch hitungan
+const maxStockTakeBatch = 500
+
+type StockTakeService struct {
+	repo *repositories.StockTakeRepository
+}
+
+func NewStockTakeService(repo *repositories.StockTakeRepository) *StockTakeService {
+	return &StockTakeService{
+		repo: repo,
+	}
+}
+
+func (s *StockTakeService) CreateStockTake(req models.StockTakeRequest, actor *models.AuthUser) (*models.StockTake, error) {
+	return s.repo.CreateStockTake(req, actor)
+}
+
+func (s *StockTakeService) GetAllStockTakes(status string) ([]models.StockTake, error) {
+	return s.repo.GetAllStockTakes(status)
+}
+
+func (s *StockTakeService) GetStockTakeReport(id string) (*models.StockTakeReport, error) {
+	return s.repo.GetStockTakeReport(id)
+}
+
+func (s *StockTakeService) SubmitCounts(id string, req models.StockTakeCountRequest, actor *models.AuthUser) (*models.StockTakeReport, error) {
+	if req.Mode == "" {
+		req.Mode = models.StockTakeCountModeSet
+	}
+
+	fieldErrors := make([]models.FieldError, 0)
+	if req.Mode != models.StockTakeCountModeSet && req.Mode != models.StockTakeCountModeAdd {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "mode", Message: "mode harus set atau add"})
+	}
+	if len(req.Items) == 0 {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "items", Message: "items tidak boleh kosong"})
+	}
+	if len(req.Items) > maxStockTakeBatch {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "items", Message: fmt.Sprintf("maksimal %d baris per batch", maxStockTakeBatch)})
+	}
+	seen := make(map[string]bool)
+	for i, item := range req.Items {
+		if item.ProductID == "" {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].product_id", i),
+				Message: "product_id wajib diisi",
+			})
+		} else if !isUUID(item.ProductID) {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].product_id", i),
+				Message: "product_id harus berupa UUID",
+			})
+		} else if seen[item.ProductID] {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].product_id", i),
+				Message: "product_id tidak boleh duplikat dalam satu batch",
+			})
+		}
+		seen[item.ProductID] = true
+		if item.CountedQuantity < 0 {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].counted_quantity", i),
+				Message: "counted_quantity tidak boleh kurang dari 0",
+			})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return nil, validationError(fieldErrors)
+	}
+
+	if err := s.repo.SubmitCounts(id, req, actor); err != nil {
+		return nil, err
+	}
+	return s.repo.GetStockTakeReport(id)
+}
+
+func (s *StockTakeService) FinalizeStockTake(id string, actor *models.AuthUser) (*models.StockTakeReport, error) {
+	if err := s.repo.FinalizeStockTake(id, actor); err != nil {
+		return nil, err
+	}
+	return s.repo.GetStockTakeReport(id)
+}
+
+func (s *StockTakeService) CancelStockTake(id string, actor *models.AuthUser) (*models.StockTakeReport, error) {
+	if err := s.repo.CancelStockTake(id, actor); err != nil {
+		return nil, err
+	}
+	return s.repo.GetStockTakeReport(id)
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/repositories"
+)
+
+type SupplierService struct {
+	repo *repositories.SupplierRepository
+}
+
+func NewSupplierService(repo *repositories.SupplierRepository) *SupplierService {
+	return &SupplierService{
+		repo: repo,
+	}
+}
+
+func (s *SupplierService) GetAllSuppliers(name string) ([]models.Supplier, error) {
+	return s.repo.GetAllSuppliers(name)
+}
+
+func (s *SupplierService) GetSupplierByID(id string) (*models.Supplier, error) {
+	return s.repo.GetSupplierByID(id)
+}
+
+func (s *SupplierService) CreateSupplier(supplier *models.Supplier) error {
+	if err := validateSupplier(supplier); err != nil {
+		return err
+	}
+	return s.repo.CreateSupplier(supplier)
+}
+
+func (s *SupplierService) UpdateSupplier(supplier *models.Supplier) error {
+	if err := validateSupplier(supplier); err != nil {
+		return err
+	}
+	return s.repo.UpdateSupplier(supplier)
+}
+
+func (s *SupplierService) DeleteSupplier(id string) error {
+	return s.repo.DeleteSupplier(id)
+}
+
+func validateSupplier(supplier *models.Supplier) error {
+	if supplier.Name == "" {
+		return validationError([]models.FieldError{
+			{Field: "name", Message: "name wajib diisi"},
+		})
+	}
+	return nil
+}
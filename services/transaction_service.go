@@ -1,20 +1,403 @@
 package services
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"labkoding.my.id/kasir-api/apperror"
 	"labkoding.my.id/kasir-api/models"
 	"labkoding.my.id/kasir-api/repositories"
 )
 
+// maxCheckoutItems membatasi jumlah baris dalam satu keranjang
+const maxCheckoutItems = 100
+
+const maxIdempotencyKeyLength = 255
+
+// ErrIdempotencyKeyMismatch dikembalikan jika Idempotency-Key dipakai ulang dengan body berbeda
+var ErrIdempotencyKeyMismatch = apperror.Unprocessable("IDEMPOTENCY_KEY_MISMATCH", "idempotency key sudah dipakai untuk request yang berbeda")
+
 type TransactionService struct {
-	repo *repositories.TransactionRepository
+	repo      *repositories.TransactionRepository
+	inventory *InventoryService
+	loc       *time.Location
 }
 
-func NewTransactionService(repo *repositories.TransactionRepository) *TransactionService {
+// NewTransactionService membuat TransactionService; loc adalah zona waktu toko
+// untuk filter tanggal
+func NewTransactionService(repo *repositories.TransactionRepository, inventory *InventoryService, loc *time.Location) *TransactionService {
 	return &TransactionService{
-		repo: repo,
+		repo:      repo,
+		inventory: inventory,
+		loc:       loc,
+	}
+}
+
+// Checkout membuat transaksi baru. Nilai replayed bernilai true jika request
+// merupakan pengulangan Idempotency-Key yang sudah pernah berhasil, dan
+// transaksi yang dikembalikan adalah transaksi dari request pertama.
+func (s *TransactionService) Checkout(req models.CheckoutRequest) (transaction *models.Transaction, replayed bool, err error) {
+	if len(req.IdempotencyKey) > maxIdempotencyKeyLength {
+		return nil, false, validationError([]models.FieldError{
+			{Field: "Idempotency-Key", Message: fmt.Sprintf("maksimal %d karakter", maxIdempotencyKeyLength)},
+		})
+	}
+
+	requestHash, err := checkoutRequestHash(req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if req.IdempotencyKey != "" {
+		transaction, err := s.replayCheckout(req, requestHash)
+		if err == nil {
+			return transaction, true, nil
+		}
+		if !errors.Is(err, repositories.ErrIdempotencyKeyNotFound) {
+			return nil, false, err
+		}
+	}
+
+	items, err := validateCheckoutItems(req.Items)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := validatePayments(req.Payments); err != nil {
+		return nil, false, err
+	}
+	if err := s.resolveProducts(items); err != nil {
+		return nil, false, err
+	}
+	req.Items = mergeCheckoutItems(items)
+
+	transaction, lowStock, err := s.repo.CreateTransaction(req, requestHash)
+	if errors.Is(err, repositories.ErrIdempotencyKeyExists) {
+		// request lain dengan key yang sama baru saja selesai lebih dulu
+		transaction, err = s.replayCheckout(req, requestHash)
+		return transaction, err == nil, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.inventory.NotifyLowStock(transaction.ID, lowStock)
+
+	return transaction, false, nil
+}
+
+// replayCheckout mengembalikan transaksi yang tersimpan untuk key tersebut.
+// Key berlaku per kasir, jadi kasir lain dengan key yang sama tidak pernah
+// menerima transaksi ini.
+func (s *TransactionService) replayCheckout(req models.CheckoutRequest, requestHash string) (*models.Transaction, error) {
+	var cashierID *string
+	if req.Cashier != nil {
+		cashierID = &req.Cashier.ID
+	}
+	idempotencyKey, err := s.repo.GetIdempotencyKey(req.IdempotencyKey, cashierID)
+	if err != nil {
+		return nil, err
+	}
+	if idempotencyKey.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyMismatch
+	}
+	return s.repo.GetTransactionByID(idempotencyKey.TransactionID)
+}
+
+// checkoutRequestHash menghitung sha256 dari body checkout dalam bentuk JSON
+// yang sudah dinormalisasi, sehingga perbedaan spasi tidak dianggap body berbeda.
+func checkoutRequestHash(req models.CheckoutRequest) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// validatePayments memeriksa metode dan nominal setiap pembayaran.
+// Kecukupan nominal terhadap total dicek di repository setelah harga dikunci.
+func validatePayments(payments []models.PaymentInput) error {
+	if len(payments) == 0 {
+		return validationError([]models.FieldError{
+			{Field: "payments", Message: "minimal satu pembayaran wajib diisi"},
+		})
+	}
+
+	fieldErrors := make([]models.FieldError, 0)
+	for i, payment := range payments {
+		if !slices.Contains(models.PaymentMethods, payment.Method) {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("payments[%d].method", i),
+				Message: "metode pembayaran harus salah satu dari " + strings.Join(models.PaymentMethods, ", "),
+			})
+		}
+		if payment.Amount <= 0 {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("payments[%d].amount", i),
+				Message: "amount harus lebih dari 0",
+			})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return validationError(fieldErrors)
+	}
+
+	return nil
+}
+
+// resolveProducts mengisi product_id untuk baris keranjang yang memakai
+// barcode dan memastikan product_id yang dikirim terdaftar. Produk yang tidak
+// ada ditolak per baris seperti error validasi lainnya, bukan 404.
+func (s *TransactionService) resolveProducts(items []models.CheckoutItem) error {
+	barcodes := make([]string, 0)
+	requestedIDs := make([]string, 0)
+	for _, item := range items {
+		if item.Barcode != "" {
+			barcodes = append(barcodes, item.Barcode)
+		} else {
+			requestedIDs = append(requestedIDs, item.ProductID)
+		}
+	}
+
+	var productIDs map[string]string
+	var existing map[string]bool
+	var err error
+	if len(barcodes) > 0 {
+		if productIDs, err = s.repo.GetProductIDsByBarcodes(barcodes); err != nil {
+			return err
+		}
+	}
+	if len(requestedIDs) > 0 {
+		if existing, err = s.repo.GetExistingProductIDs(requestedIDs); err != nil {
+			return err
+		}
+	}
+
+	fieldErrors := make([]models.FieldError, 0)
+	for i := range items {
+		if items[i].Barcode == "" {
+			if !existing[items[i].ProductID] {
+				fieldErrors = append(fieldErrors, models.FieldError{
+					Field:   fmt.Sprintf("items[%d].product_id", i),
+					Message: "produk tidak ditemukan",
+				})
+			}
+			continue
+		}
+		productID, ok := productIDs[items[i].Barcode]
+		if !ok {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].barcode", i),
+				Message: "barcode tidak terdaftar",
+			})
+			continue
+		}
+		items[i].ProductID = productID
 	}
+	if len(fieldErrors) > 0 {
+		return validationError(fieldErrors)
+	}
+	return nil
+}
+
+// validateCheckoutItems memeriksa setiap baris keranjang dan menormalkan
+// barcode ke EAN-13. Index pada field error mengacu ke urutan item di request
+// agar UI bisa menandai baris yang salah. Slice yang dikembalikan adalah
+// salinan sehingga request asli tidak berubah.
+func validateCheckoutItems(items []models.CheckoutItem) ([]models.CheckoutItem, error) {
+	if len(items) == 0 {
+		return nil, validationError([]models.FieldError{
+			{Field: "items", Message: "keranjang tidak boleh kosong"},
+		})
+	}
+	if len(items) > maxCheckoutItems {
+		return nil, validationError([]models.FieldError{
+			{Field: "items", Message: fmt.Sprintf("maksimal %d baris per transaksi", maxCheckoutItems)},
+		})
+	}
+
+	validated := make([]models.CheckoutItem, len(items))
+	fieldErrors := make([]models.FieldError, 0)
+	for i, item := range items {
+		item.Barcode = strings.TrimSpace(item.Barcode)
+		switch {
+		case item.ProductID == "" && item.Barcode == "":
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].product_id", i),
+				Message: "product_id atau barcode wajib diisi",
+			})
+		case item.ProductID != "" && item.Barcode != "":
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].barcode", i),
+				Message: "isi salah satu dari product_id atau barcode",
+			})
+		case item.ProductID != "" && !isUUID(item.ProductID):
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].product_id", i),
+				Message: "product_id harus berupa UUID",
+			})
+		case item.Barcode != "":
+			barcode, err := normalizeBarcode(item.Barcode)
+			if err != nil {
+				fieldErrors = append(fieldErrors, models.FieldError{
+					Field:   fmt.Sprintf("items[%d].barcode", i),
+					Message: err.Error(),
+				})
+			}
+			item.Barcode = barcode
+		}
+		if item.Quantity <= 0 {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].quantity", i),
+				Message: "quantity harus lebih dari 0",
+			})
+		}
+		validated[i] = item
+	}
+	if len(fieldErrors) > 0 {
+		return nil, validationError(fieldErrors)
+	}
+
+	return validated, nil
+}
+
+// mergeCheckoutItems menggabungkan baris dengan product_id yang sama, termasuk
+// baris yang di-scan lewat barcode berbeda milik produk yang sama
+func mergeCheckoutItems(items []models.CheckoutItem) []models.CheckoutItem {
+	merged := make([]models.CheckoutItem, 0, len(items))
+	index := make(map[string]int)
+	for _, item := range items {
+		if i, ok := index[item.ProductID]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.ProductID] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
 }
 
-func (s *TransactionService) Checkout(items []models.CheckoutItem) (*models.Transaction, error) {
-	return s.repo.CreateTransaction(items)
+func (s *TransactionService) GetAllTransactions(filter models.TransactionFilter) (*models.TransactionListResponse, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 {
+		filter.Limit = 20
+	}
+	if filter.Limit > 100 {
+		filter.Limit = 100
+	}
+
+	if err := validateTransactionFilter(filter); err != nil {
+		return nil, err
+	}
+
+	var err error
+	filter.CreatedFrom, filter.CreatedTo, err = parseDateRange(s.loc, filter.StartDate, filter.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, total, err := s.repo.GetAllTransactions(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TransactionListResponse{
+		Data:  transactions,
+		Page:  filter.Page,
+		Limit: filter.Limit,
+		Total: total,
+	}, nil
+}
+
+// ExportTransactions memanggil fn untuk setiap transaksi yang cocok dengan
+// filter, tanpa pagination. Tanggal ditulis di zona waktu toko, sama dengan
+// filter start_date dan end_date.
+func (s *TransactionService) ExportTransactions(filter models.TransactionFilter, fn func(models.TransactionExportRow) error) error {
+	if err := validateTransactionFilter(filter); err != nil {
+		return err
+	}
+
+	var err error
+	filter.CreatedFrom, filter.CreatedTo, err = parseDateRange(s.loc, filter.StartDate, filter.EndDate)
+	if err != nil {
+		return err
+	}
+	return s.repo.ExportTransactions(filter, func(row models.TransactionExportRow) error {
+		row.CreatedAt = row.CreatedAt.In(s.loc)
+		return fn(row)
+	})
+}
+
+func validateTransactionFilter(filter models.TransactionFilter) error {
+	return validateUUIDs(
+		uuidField{"product_id", filter.ProductID},
+		uuidField{"shift_id", filter.ShiftID},
+	)
+}
+
+func (s *TransactionService) GetTransactionByID(id string) (*models.Transaction, error) {
+	return s.repo.GetTransactionByID(id)
+}
+
+func (s *TransactionService) VoidTransaction(id string, req models.VoidRequest, actor *models.AuthUser) (*models.Refund, error) {
+	if req.Reason == "" {
+		return nil, validationError([]models.FieldError{
+			{Field: "reason", Message: "alasan pembatalan wajib diisi"},
+		})
+	}
+	return s.repo.VoidTransaction(id, req.Reason, actor)
+}
+
+func (s *TransactionService) RefundTransaction(id string, req models.RefundRequest, actor *models.AuthUser) (*models.Refund, error) {
+	fieldErrors := make([]models.FieldError, 0)
+	if req.Reason == "" {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "reason", Message: "alasan refund wajib diisi"})
+	}
+	if len(req.Items) == 0 {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: "items", Message: "items refund tidak boleh kosong"})
+	}
+	for i, item := range req.Items {
+		if item.TransactionDetailID == "" {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].transaction_detail_id", i),
+				Message: "transaction_detail_id wajib diisi",
+			})
+		} else if !isUUID(item.TransactionDetailID) {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].transaction_detail_id", i),
+				Message: "transaction_detail_id harus berupa UUID",
+			})
+		}
+		if item.Quantity <= 0 {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("items[%d].quantity", i),
+				Message: "quantity harus lebih dari 0",
+			})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return nil, validationError(fieldErrors)
+	}
+
+	merged := make([]models.RefundItem, 0, len(req.Items))
+	index := make(map[string]int)
+	for _, item := range req.Items {
+		if i, ok := index[item.TransactionDetailID]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.TransactionDetailID] = len(merged)
+		merged = append(merged, item)
+	}
+	req.Items = merged
+
+	return s.repo.RefundTransaction(id, req, actor)
 }
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"labkoding.my.id/kasir-api/models"
+	"labkoding.my.id/kasir-api/repositories"
+)
+
+const minPasswordLength = 8
+
+// bcrypt hanya memakai 72 byte pertama password
+const maxPasswordLength = 72
+
+type UserService struct {
+	repo *repositories.UserRepository
+}
+
+func NewUserService(repo *repositories.UserRepository) *UserService {
+	return &UserService{
+		repo: repo,
+	}
+}
+
+// EnsureOwner membuat akun owner pertama jika tabel users masih kosong.
+// Dipanggil saat aplikasi start supaya ada akun untuk login pertama kali.
+func (s *UserService) EnsureOwner(username, password string) (bool, error) {
+	total, err := s.repo.CountUsers()
+	if err != nil || total > 0 {
+		return false, err
+	}
+
+	active := true
+	_, err = s.CreateUser(models.UserRequest{
+		Username: username,
+		Name:     username,
+		Password: password,
+		Role:     models.RoleOwner,
+		Active:   &active,
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *UserService) GetAllUsers() ([]models.User, error) {
+	return s.repo.GetAllUsers()
+}
+
+func (s *UserService) GetUserByID(id string) (*models.User, error) {
+	return s.repo.GetUserByID(id)
+}
+
+func (s *UserService) CreateUser(req models.UserRequest) (*models.User, error) {
+	if err := validateUser(req, true); err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Username:     strings.TrimSpace(req.Username),
+		Name:         req.Name,
+		Role:         req.Role,
+		Active:       req.Active == nil || *req.Active,
+		PasswordHash: string(hash),
+	}
+	if err := s.repo.CreateUser(user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// UpdateUser mengubah data user. actor adalah user yang sedang login; owner
+// tidak boleh menurunkan role atau menonaktifkan akunnya sendiri agar tidak
+// terkunci dari pengelolaan user.
+func (s *UserService) UpdateUser(actor models.AuthUser, id string, req models.UserRequest) (*models.User, error) {
+	if err := validateUser(req, false); err != nil {
+		return nil, err
+	}
+
+	user, err := s.repo.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	active := req.Active == nil || *req.Active
+	if user.ID == actor.ID && (req.Role != user.Role || !active) {
+		return nil, validationError([]models.FieldError{
+			{Field: "role", Message: "tidak bisa mengubah role atau menonaktifkan akun sendiri"},
+		})
+	}
+
+	user.Username = strings.TrimSpace(req.Username)
+	user.Name = req.Name
+	user.Role = req.Role
+	user.Active = active
+	if req.Password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		user.PasswordHash = string(hash)
+	}
+
+	if err := s.repo.UpdateUser(user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *UserService) DeleteUser(actor models.AuthUser, id string) error {
+	if id == actor.ID {
+		return validationError([]models.FieldError{
+			{Field: "id", Message: "tidak bisa menghapus akun sendiri"},
+		})
+	}
+	return s.repo.DeleteUser(id)
+}
+
+func validateUser(req models.UserRequest, passwordRequired bool) error {
+	fieldErrors := make([]models.FieldError, 0)
+	addError := func(field, message string) {
+		fieldErrors = append(fieldErrors, models.FieldError{Field: field, Message: message})
+	}
+
+	if strings.TrimSpace(req.Username) == "" {
+		addError("username", "username wajib diisi")
+	}
+	if req.Name == "" {
+		addError("name", "name wajib diisi")
+	}
+	if !slices.Contains(models.Roles, req.Role) {
+		addError("role", "role harus salah satu dari "+strings.Join(models.Roles, ", "))
+	}
+	if req.Password != "" || passwordRequired {
+		if len(req.Password) < minPasswordLength {
+			addError("password", "password minimal 8 karakter")
+		} else if len(req.Password) > maxPasswordLength {
+			addError("password", "password maksimal 72 karakter")
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return validationError(fieldErrors)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"regexp"
+
+	"labkoding.my.id/kasir-api/apperror"
+	"labkoding.my.id/kasir-api/models"
+)
+
+// validationError membungkus daftar field yang tidak valid ke dalam apperror
+// sehingga handler bisa menampilkan error per field, contoh "items[1].quantity".
+func validationError(fieldErrors []models.FieldError) error {
+	return apperror.ErrValidation.WithDetails(fieldErrors)
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// isUUID mengecek format id. Postgres menolak UUID yang formatnya salah
+// dengan error, sehingga id dari body dan query dicek lebih dulu.
+func isUUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
+
+// uuidField adalah id opsional dari body atau query beserta nama field-nya
+type uuidField struct {
+	name  string
+	value string
+}
+
+// validateUUIDs menolak id yang terisi tetapi bukan UUID
+func validateUUIDs(fields ...uuidField) error {
+	fieldErrors := make([]models.FieldError, 0)
+	for _, field := range fields {
+		if field.value != "" && !isUUID(field.value) {
+			fieldErrors = append(fieldErrors, models.FieldError{Field: field.name, Message: field.name + " harus berupa UUID"})
+		}
+	}
+	if len(fieldErrors) > 0 {
+		return validationError(fieldErrors)
+	}
+	return nil
+}